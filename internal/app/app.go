@@ -1,61 +1,187 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"marketplace-notifications/internal/auth"
 	"marketplace-notifications/internal/client"
 	"marketplace-notifications/internal/config"
-	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/metrics"
 	"marketplace-notifications/internal/monitor"
+	"marketplace-notifications/internal/store"
 	"marketplace-notifications/internal/telegram"
 	"marketplace-notifications/internal/utils/ip"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 type App struct {
-	config  *config.ServerConfig
-	monitor *monitor.Monitor
+	configPath      string
+	configMutex     sync.RWMutex
+	config          *config.Config
+	ctx             context.Context
+	background      sync.WaitGroup
+	reloadMutex     sync.Mutex
+	lastReload      time.Time
+	reloadError     error
+	store           *store.Store
+	authenticator   *auth.Authenticator
+	yandexAllowlist ip.Allowlist
+	trustedProxies  ip.Allowlist
+	monitor         *monitor.Monitor
+	notifier        *telegram.TelegramNotifier
+	bot             *telegram.TelegramBot
 }
 
-func NewApp() *App {
-	log.SetOutput(os.Stdout)
+func NewApp(configPath string) *App {
+	logging.Setup(os.Stdout, "info", "text")
 
 	if err := godotenv.Load(); err != nil {
-		log.Printf("[WARN] Warning: Could not load .env file: %v", err)
-		log.Println("[INFO] Assuming environment variables are set directly")
+		slog.Warn("Could not load .env file, assuming environment variables are set directly", "error", err)
 	}
 
-	config, err := config.Load()
+	config, err := config.Load(configPath)
 	if err != nil {
-		log.Fatal("[ERROR] Failed to load config: ", err)
+		fatal("Failed to load config", err)
+	}
+
+	logging.AddSecrets(config.Secrets()...)
+
+	if err := logging.Setup(os.Stdout, config.Log.Level, config.Log.Format); err != nil {
+		fatal("Failed to set up logging", err)
+	}
+
+	yandexAllowlist, err := ip.ParseAllowlist(config.Server.YandexAllowedIPs)
+	if err != nil {
+		fatal("Failed to parse the Yandex webhook allowlist", err)
+	}
+
+	trustedProxies, err := ip.ParseAllowlist(config.Server.TrustedProxies)
+	if err != nil {
+		fatal("Failed to parse the trusted proxies", err)
+	}
+
+	store, err := store.Open(config.Store.Path)
+	if err != nil {
+		fatal("Failed to open store", err)
+	}
+
+	if err := store.SeedSubscriptions(config.Telegram.Chats); err != nil {
+		fatal("Failed to seed chat subscriptions", err)
 	}
 
 	apiClient := client.NewAPIClient(&config.API)
-	notifier := telegram.NewTelegramNotifier(&config.Telegram)
-	monitor := monitor.NewMonitor(&config.Monitor, apiClient, notifier)
+	notifier, err := telegram.NewTelegramNotifier(&config.Telegram, store)
+	if err != nil {
+		fatal("Failed to create the Telegram notifier", err)
+	}
+	monitor := monitor.NewMonitor(&config.Monitor, apiClient, notifier, store)
+	bot := telegram.NewTelegramBot(&config.Telegram, notifier, store, monitor)
 
 	return &App{
-		config:  &config.Server,
-		monitor: monitor,
+		configPath:      configPath,
+		config:          config,
+		store:           store,
+		authenticator:   auth.NewAuthenticator(&config.Server.Auth),
+		yandexAllowlist: yandexAllowlist,
+		trustedProxies:  trustedProxies,
+		monitor:         monitor,
+		notifier:        notifier,
+		bot:             bot,
 	}
 }
 
-func (app *App) Run() {
-	router := gin.Default()
+// Run serves the API and runs the background components until SIGINT or
+// SIGTERM, then shuts everything down gracefully.
+func (app *App) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app.ctx = ctx
 
-	router.GET("/info", app.getInfo)
-	router.POST("/start", app.start)
-	router.POST("/stop", app.stop)
+	router, err := app.newRouter()
+	if err != nil {
+		return err
+	}
+
+	app.runInBackground(app.bot.Run)
+	app.runInBackground(app.watchConfig)
+	app.runInBackground(app.monitor.RunTokenChecks)
+	app.runInBackground(app.monitor.RunReports)
+	app.runInBackground(app.monitor.RunYandexWorkers)
+
+	app.monitor.Restore(ctx)
+
+	// Requests must not be cancelled by the signal, server.Shutdown lets the
+	// ones in flight finish.
+	server := &http.Server{
+		Addr:        fmt.Sprintf(":%d", app.currentConfig().Server.Port),
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", server.Addr)
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErrors:
+		stop()
+		app.shutdown(nil)
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+		slog.Info("Received shutdown signal")
+		return app.shutdown(server)
+	}
+}
+
+func (app *App) newRouter() (*gin.Engine, error) {
+	if app.currentConfig().Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(requestLogger(), gin.Recovery())
+
+	// Forwarded headers are only honored when they come from these proxies,
+	// otherwise anyone could claim to be Yandex.
+	if err := router.SetTrustedProxies(app.currentConfig().Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	router.GET("/healthz", app.getHealth)
+	router.GET("/readyz", app.getReadiness)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/info", app.requireRole(config.RoleRead), app.getInfo)
+	router.POST("/start", app.requireRole(config.RoleOperator), app.start)
+	router.POST("/stop", app.requireRole(config.RoleOperator), app.stop)
 	router.POST("/api/notification", app.handleNotification)
+	router.GET("/api/v1/reactions", app.requireRole(config.RoleRead), app.listReactions)
+	router.GET("/api/v1/reactions/export", app.requireRole(config.RoleRead), app.exportReactions)
+	router.GET("/api/v1/reactions/:id", app.requireRole(config.RoleRead), app.getReaction)
+	router.GET("/api/v1/templates", app.requireRole(config.RoleRead), app.listTemplates)
+	router.PUT("/api/v1/templates/:id", app.requireRole(config.RoleOperator), app.putTemplate)
+	router.DELETE("/api/v1/templates/:id", app.requireRole(config.RoleOperator), app.deleteTemplate)
+
+	app.registerDashboard(router)
 
-	router.Run(fmt.Sprintf(":%d", app.config.Port))
+	return router, nil
 }
 
 func (app *App) getInfo(c *gin.Context) {
@@ -63,56 +189,62 @@ func (app *App) getInfo(c *gin.Context) {
 }
 
 func (app *App) start(c *gin.Context) {
-	if token := c.Query("token"); token != app.config.ControlToken {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing on incorrect control token"})
-		return
-	}
-
 	if app.monitor.IsRunning() {
 		c.JSON(http.StatusOK, gin.H{"message": "already running"})
 		return
 	}
 
-	app.monitor.Start()
+	app.monitor.Start(app.ctx, requester(c))
 
 	c.JSON(http.StatusOK, gin.H{"message": "started running"})
 }
 
 func (app *App) stop(c *gin.Context) {
-	if token := c.Query("token"); token != app.config.ControlToken {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing on incorrect control token"})
-		return
-	}
-
 	if !app.monitor.IsRunning() {
 		c.JSON(http.StatusOK, gin.H{"message": "monitor is not running"})
 		return
 	}
 
-	app.monitor.Stop()
+	app.monitor.Stop(requester(c))
 
 	c.JSON(http.StatusOK, gin.H{"message": "running stops..."})
 }
 
+// requester describes who made a control request, for /info and the logs.
+func requester(c *gin.Context) string {
+	return "token " + c.GetString(tokenNameKey)
+}
+
 func (app *App) handleNotification(c *gin.Context) {
-	clientIP := net.ParseIP(c.ClientIP())
-	if clientIP == nil {
+	clientIP, err := netip.ParseAddr(c.ClientIP())
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to determine client IP"})
 		return
 	}
 
-	if ip.IsInWhitelist(clientIP, yandex.IPWhitelist) {
+	if app.yandexAllowlist.Contains(clientIP) {
 		defer c.Request.Body.Close()
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			metrics.YandexWebhooks.Inc("unknown", "invalid")
 			c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read request body"})
 			return
 		}
 
 		rawNotification := json.RawMessage(body)
 
-		if err := app.monitor.HandleYandexNotification(rawNotification); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		err = app.monitor.EnqueueYandexNotification(c.Request.Context(), rawNotification)
+		switch {
+		case errors.Is(err, monitor.ErrInvalidNotification):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, monitor.ErrNotAccepting):
+			// Acknowledged, so that Yandex doesn't retry notifications that
+			// would never be processed.
+			c.JSON(http.StatusOK, gin.H{"message": "ignored", "reason": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
@@ -120,5 +252,6 @@ func (app *App) handleNotification(c *gin.Context) {
 		return
 	}
 
+	metrics.YandexWebhooks.Inc("unknown", "forbidden")
 	c.JSON(http.StatusForbidden, gin.H{"error": "IP is not in the whitelist"})
 }
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"marketplace-notifications/internal/auth"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tokenNameKey = "tokenName"
+
+// requireRole authenticates the bearer token of the request and audits every
+// operator action and every rejected attempt.
+func (app *App) requireRole(role config.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		action := c.Request.Method + " " + c.FullPath()
+
+		token, err := app.authenticator.Authenticate(c.ClientIP(), c.GetHeader("Authorization"))
+		switch {
+		case errors.Is(err, auth.ErrLockedOut):
+			logging.FromContext(ctx).Warn("Audit", "action", action, "result", "locked out", "clientIp", c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			logging.FromContext(ctx).Warn("Audit", "action", action, "result", err.Error(), "clientIp", c.ClientIP())
+			c.Header("WWW-Authenticate", `Bearer realm="marketplace-notifications"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		case !token.Role.Allows(role):
+			logging.FromContext(ctx).Warn("Audit", "action", action, "result", "forbidden", "token", token.Name, "clientIp", c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token is not allowed to do this"})
+			return
+		}
+
+		ctx, logger := logging.With(ctx, "token", token.Name)
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(tokenNameKey, token.Name)
+
+		c.Next()
+
+		if role == config.RoleOperator {
+			logger.Info("Audit", "action", action, "result", "done", "status", c.Writer.Status(), "clientIp", c.ClientIP())
+		}
+	}
+}
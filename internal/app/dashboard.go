@@ -0,0 +1,255 @@
+package app
+
+import (
+	"crypto/subtle"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"marketplace-notifications/internal/auth"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/store"
+	"net/http"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookie      = "session"
+	sessionKey         = "session"
+	dashboardPath      = "/dashboard"
+	dashboardItemLimit = 200
+)
+
+//go:embed dashboard/*.html
+var dashboardFiles embed.FS
+
+var dashboardTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"formatTime": formatDashboardTime,
+}).ParseFS(dashboardFiles, "dashboard/*.html"))
+
+type dashboardItem struct {
+	store.Reaction
+	Age        string
+	Deliveries []chatDelivery
+}
+
+// chatDelivery is the outcome of the latest delivery to a chat.
+type chatDelivery struct {
+	ChatId string
+	Action store.DeliveryAction
+	Error  string
+	At     time.Time
+}
+
+func (app *App) registerDashboard(router *gin.Engine) {
+	router.GET(dashboardPath+"/login", app.dashboardLoginForm)
+	router.POST(dashboardPath+"/login", app.dashboardLogin)
+	router.POST(dashboardPath+"/logout", app.requireSession(config.RoleRead), app.dashboardLogout)
+	router.GET(dashboardPath, app.requireSession(config.RoleRead), app.dashboard)
+	router.POST(dashboardPath+"/start", app.requireSession(config.RoleOperator), app.dashboardStart)
+	router.POST(dashboardPath+"/stop", app.requireSession(config.RoleOperator), app.dashboardStop)
+}
+
+// requireSession is requireRole for the dashboard: the browser signs in once
+// and every form it posts must carry the session's CSRF token.
+func (app *App) requireSession(role config.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		action := c.Request.Method + " " + c.FullPath()
+
+		id, _ := c.Cookie(sessionCookie)
+		session, ok := app.authenticator.Session(id)
+		if !ok {
+			c.Redirect(http.StatusSeeOther, dashboardPath+"/login")
+			c.Abort()
+			return
+		}
+
+		if c.Request.Method == http.MethodPost && subtle.ConstantTimeCompare([]byte(c.PostForm("csrf")), []byte(session.CSRF)) != 1 {
+			logging.FromContext(ctx).Warn("Audit", "action", action, "result", "invalid CSRF token", "token", session.Token.Name, "clientIp", c.ClientIP())
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		if !session.Token.Role.Allows(role) {
+			logging.FromContext(ctx).Warn("Audit", "action", action, "result", "forbidden", "token", session.Token.Name, "clientIp", c.ClientIP())
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx, logger := logging.With(ctx, "token", session.Token.Name)
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(tokenNameKey, session.Token.Name)
+		c.Set(sessionKey, session)
+
+		c.Next()
+
+		if role == config.RoleOperator {
+			logger.Info("Audit", "action", action, "result", "done", "status", c.Writer.Status(), "clientIp", c.ClientIP())
+		}
+	}
+}
+
+func (app *App) dashboardLoginForm(c *gin.Context) {
+	renderDashboard(c, http.StatusOK, "login.html", gin.H{})
+}
+
+func (app *App) dashboardLogin(c *gin.Context) {
+	session, err := app.authenticator.Login(c.ClientIP(), c.PostForm("token"))
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Warn("Audit", "action", "dashboard login", "result", err.Error(), "clientIp", c.ClientIP())
+
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, auth.ErrLockedOut):
+			status = http.StatusTooManyRequests
+		case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrMissingToken):
+			status = http.StatusUnauthorized
+		}
+
+		renderDashboard(c, status, "login.html", gin.H{"Error": err.Error()})
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("Audit", "action", "dashboard login", "result", "done", "token", session.Token.Name, "clientIp", c.ClientIP())
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(sessionCookie, session.Id, 0, dashboardPath, "", app.isHTTPS(c), true)
+	c.Redirect(http.StatusSeeOther, dashboardPath)
+}
+
+func (app *App) dashboardLogout(c *gin.Context) {
+	app.authenticator.Logout(c.MustGet(sessionKey).(auth.Session).Id)
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(sessionCookie, "", -1, dashboardPath, "", app.isHTTPS(c), true)
+	c.Redirect(http.StatusSeeOther, dashboardPath+"/login")
+}
+
+func (app *App) dashboard(c *gin.Context) {
+	session := c.MustGet(sessionKey).(auth.Session)
+
+	data := gin.H{
+		"Info":       app.monitor.GetInfo(),
+		"CSRF":       session.CSRF,
+		"TokenName":  session.Token.Name,
+		"CanOperate": session.Token.Role.Allows(config.RoleOperator),
+		"Query":      c.Request.URL.Query(),
+	}
+
+	filter, err := parseReactionFilter(c.Query)
+	if err != nil {
+		data["Error"] = err.Error()
+		renderDashboard(c, http.StatusBadRequest, "index.html", data)
+		return
+	}
+
+	// Only what still waits for an answer, oldest first unless asked otherwise.
+	unanswered := false
+	filter.Answered = &unanswered
+	if c.Query("order") == "" {
+		filter.OldestFirst = true
+	}
+
+	page, err := app.store.Reactions(filter, "", dashboardItemLimit)
+	if err != nil {
+		data["Error"] = err.Error()
+		renderDashboard(c, http.StatusInternalServerError, "index.html", data)
+		return
+	}
+
+	items := make([]dashboardItem, 0, len(page.Items))
+	for _, reaction := range page.Items {
+		if withHistory, ok := app.store.Reaction(reaction.Id); ok {
+			reaction = withHistory
+		}
+
+		items = append(items, dashboardItem{
+			Reaction:   reaction,
+			Age:        formatAge(time.Since(reaction.CreatedAt)),
+			Deliveries: latestDeliveries(reaction.Deliveries),
+		})
+	}
+
+	data["Items"] = items
+	data["Truncated"] = page.NextCursor != ""
+
+	renderDashboard(c, http.StatusOK, "index.html", data)
+}
+
+func (app *App) dashboardStart(c *gin.Context) {
+	app.monitor.Start(app.ctx, requester(c))
+	c.Redirect(http.StatusSeeOther, dashboardPath)
+}
+
+func (app *App) dashboardStop(c *gin.Context) {
+	app.monitor.Stop(requester(c))
+	c.Redirect(http.StatusSeeOther, dashboardPath)
+}
+
+func renderDashboard(c *gin.Context, status int, name string, data gin.H) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+
+	if err := dashboardTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Failed to render dashboard", "template", name, "error", err)
+	}
+}
+
+// isHTTPS trusts X-Forwarded-Proto only from the trusted proxies, like the
+// client IP.
+func (app *App) isHTTPS(c *gin.Context) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+
+	remoteIP, err := netip.ParseAddr(c.RemoteIP())
+	return err == nil && app.trustedProxies.Contains(remoteIP) && c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+func latestDeliveries(deliveries []store.Delivery) []chatDelivery {
+	latest := make(map[string]chatDelivery)
+	for _, delivery := range deliveries {
+		if delivery.Action != store.DeliverySend && delivery.Action != store.DeliveryEdit {
+			continue
+		}
+		latest[delivery.ChatId] = chatDelivery{ChatId: delivery.ChatId, Action: delivery.Action, Error: delivery.Error, At: delivery.At}
+	}
+
+	result := make([]chatDelivery, 0, len(latest))
+	for _, delivery := range latest {
+		result = append(result, delivery)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChatId < result[j].ChatId
+	})
+
+	return result
+}
+
+func formatAge(age time.Duration) string {
+	switch {
+	case age >= 24*time.Hour:
+		return fmt.Sprintf("%d д %d ч", int(age.Hours())/24, int(age.Hours())%24)
+	case age >= time.Hour:
+		return fmt.Sprintf("%d ч %d мин", int(age.Hours()), int(age.Minutes())%60)
+	default:
+		return fmt.Sprintf("%d мин", int(age.Minutes()))
+	}
+}
+
+func formatDashboardTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+
+	return t.Local().Format(time.DateTime)
+}
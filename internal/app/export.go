@@ -0,0 +1,125 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/export"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/store"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+// exportReactions downloads the stored questions and feedbacks matching the
+// filters of listReactions as a file.
+func (app *App) exportReactions(c *gin.Context) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.CSV)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseReactionFilter(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reactions := app.store.AllReactions(filter)
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.FileName(time.Now())))
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer, format, reactions); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Failed to export reactions", "format", format, "error", err)
+	}
+}
+
+// RunExport is the export command: it writes the stored questions and
+// feedbacks to a file or stdout without starting the service.
+func RunExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+
+	configPath := flags.String("config", "", "path to an optional YAML config file")
+	storePath := flags.String("store", "", "path to the store file, instead of the one in the config")
+	formatName := flags.String("format", string(export.CSV), "csv, xlsx or ndjson")
+	output := flags.String("output", "", "file to write, stdout when empty")
+
+	params := make(map[string]*string)
+	for name, usage := range map[string]string{
+		"marketplace": "only WB or Yandex",
+		"type":        "only questions or feedbacks",
+		"article":     "only this product article",
+		"tag":         "only ones with this tag",
+		"rating":      "only these ratings, comma separated",
+		"from":        "only created at or after this RFC 3339 time",
+		"to":          "only created before this RFC 3339 time",
+		"answered":    "only answered (true) or unanswered (false) ones",
+		"order":       "newest (default) or oldest first",
+	} {
+		params[name] = flags.String(name, "", usage)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseReactionFilter(func(name string) string { return *params[name] })
+	if err != nil {
+		return err
+	}
+
+	if *storePath == "" {
+		if err := godotenv.Load(); err != nil {
+			slog.Warn("Could not load .env file, assuming environment variables are set directly", "error", err)
+		}
+
+		config, err := config.Load(*configPath)
+		if err != nil {
+			return err
+		}
+		*storePath = config.Store.Path
+	}
+
+	// A running service keeps owning the file, it is never written here.
+	reactionStore, err := store.OpenReadOnly(*storePath)
+	if err != nil {
+		return err
+	}
+
+	reactions := reactionStore.AllReactions(filter)
+
+	if *output == "" {
+		return export.Write(os.Stdout, format, reactions)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *output, err)
+	}
+
+	if err := export.Write(file, format, reactions); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to export reactions: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+
+	slog.Info("Exported reactions", "count", len(reactions), "format", format, "output", *output)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"marketplace-notifications/internal/health"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 5 * time.Second
+
+type reloadStatus struct {
+	Path       string    `json:"path,omitempty"`
+	LastReload time.Time `json:"lastReload,omitzero"`
+}
+
+func (app *App) getHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOk})
+}
+
+func (app *App) getReadiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	report := health.Check(ctx, map[string]health.Checker{
+		"config":   app.checkConfig,
+		"store":    app.checkStore,
+		"sources":  app.checkSources,
+		"yandex":   app.checkYandex,
+		"telegram": app.notifier.CheckConnection,
+	})
+
+	status := http.StatusOK
+	if report.Status != health.StatusOk {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
+
+func (app *App) checkConfig(ctx context.Context) health.Component {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	status := reloadStatus{Path: app.configPath, LastReload: app.lastReload}
+
+	if app.reloadError != nil {
+		return health.Fail("last config reload was rejected: "+app.reloadError.Error(), status)
+	}
+
+	return health.Ok("", status)
+}
+
+func (app *App) checkStore(ctx context.Context) health.Component {
+	if err := app.store.CheckWritable(); err != nil {
+		return health.Fail(err.Error(), nil)
+	}
+
+	return health.Ok("", nil)
+}
+
+func (app *App) checkSources(ctx context.Context) health.Component {
+	return app.monitor.CheckSourcesReadiness(app.currentConfig().Server.ReadinessMaxMissedChecks)
+}
+
+func (app *App) checkYandex(ctx context.Context) health.Component {
+	return app.monitor.CheckYandexReadiness()
+}
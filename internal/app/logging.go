@@ -0,0 +1,48 @@
+package app
+
+import (
+	"log/slog"
+	"marketplace-notifications/internal/logging"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quietPaths are polled by probes and scrapers and only logged at debug level.
+var quietPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// requestLogger replaces gin's default logger. Every request gets a
+// correlation ID that handlers pick up with logging.FromContext.
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		ctx, logger := logging.WithCorrelationId(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		level := slog.LevelInfo
+		if quietPaths[c.Request.URL.Path] {
+			level = slog.LevelDebug
+		}
+
+		logger.Log(ctx, level, "HTTP request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"clientIp", c.ClientIP(),
+		)
+	}
+}
+
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultReactionsLimit = 50
+	maxReactionsLimit     = 500
+)
+
+// listReactions serves the history of received questions and feedbacks,
+// newest first, a page at a time.
+func (app *App) listReactions(c *gin.Context) {
+	filter, err := parseReactionFilter(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := defaultReactionsLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxReactionsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxReactionsLimit)})
+			return
+		}
+	}
+
+	page, err := app.store.Reactions(filter, c.Query("cursor"), limit)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// getReaction serves a single question or feedback with the delivery history
+// of its notification.
+func (app *App) getReaction(c *gin.Context) {
+	reaction, ok := app.store.Reaction(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reaction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reaction)
+}
+
+// parseReactionFilter reads the filter from named parameters, the query
+// string of a request or the flags of the export command.
+func parseReactionFilter(param func(name string) string) (store.ReactionFilter, error) {
+	filter := store.ReactionFilter{
+		Marketplace: param("marketplace"),
+		Type:        param("type"),
+		Article:     param("article"),
+		Tag:         param("tag"),
+	}
+
+	if filter.Type != "" && filter.Type != marketplaces.Question.String() && filter.Type != marketplaces.Feedback.String() {
+		return filter, fmt.Errorf("type must be %s or %s", marketplaces.Question, marketplaces.Feedback)
+	}
+
+	if value := param("rating"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			rating, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || rating < 1 || rating > 5 {
+				return filter, fmt.Errorf("rating must be a comma separated list of numbers from 1 to 5")
+			}
+			filter.Ratings = append(filter.Ratings, rating)
+		}
+	}
+
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := param(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time", name)
+		}
+		*target = parsed
+	}
+
+	switch param("order") {
+	case "", "newest":
+	case "oldest":
+		filter.OldestFirst = true
+	default:
+		return filter, fmt.Errorf("order must be newest or oldest")
+	}
+
+	if value := param("answered"); value != "" {
+		answered, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("answered must be true or false")
+		}
+		filter.Answered = &answered
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/logging"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+)
+
+// watchConfig reloads the config on SIGHUP and, when a config file is used,
+// whenever the file changes.
+func (app *App) watchConfig(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var fileChanges <-chan time.Time
+	var lastModified time.Time
+
+	watchInterval := app.currentConfig().Reload.WatchInterval
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	if app.configPath != "" {
+		lastModified = modificationTime(app.configPath)
+		fileChanges = ticker.C
+	}
+
+	for {
+		select {
+		case <-hangups:
+			slog.Info("Received SIGHUP, reloading config")
+			app.reloadConfig()
+		case <-fileChanges:
+			if modified := modificationTime(app.configPath); !modified.Equal(lastModified) {
+				lastModified = modified
+				slog.Info("Config file changed, reloading config", "path", app.configPath)
+				app.reloadConfig()
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		if interval := app.currentConfig().Reload.WatchInterval; interval != watchInterval {
+			watchInterval = interval
+			ticker.Reset(watchInterval)
+		}
+	}
+}
+
+func (app *App) reloadConfig() {
+	newConfig, err := config.Load(app.configPath)
+	if err != nil {
+		slog.Error("Refusing to apply invalid config", "error", err)
+		app.setReloadResult(err)
+		return
+	}
+
+	logging.AddSecrets(newConfig.Secrets()...)
+
+	if err := app.notifier.UpdateConfig(&newConfig.Telegram); err != nil {
+		slog.Error("Failed to apply Telegram config", "error", err)
+		app.setReloadResult(err)
+		return
+	}
+	app.monitor.UpdateConfig(&newConfig.Monitor)
+	app.authenticator.UpdateConfig(&newConfig.Server.Auth)
+	logging.SetLevel(newConfig.Log.Level)
+
+	app.configMutex.Lock()
+	oldConfig := app.config
+	app.config = newConfig
+	app.configMutex.Unlock()
+
+	if !reflect.DeepEqual(restartOnlyServerConfig(oldConfig.Server), restartOnlyServerConfig(newConfig.Server)) ||
+		!reflect.DeepEqual(oldConfig.API, newConfig.API) ||
+		!reflect.DeepEqual(oldConfig.Store, newConfig.Store) ||
+		oldConfig.Log.Format != newConfig.Log.Format {
+		slog.Warn("Changes to server, API, store or log format settings take effect after a restart")
+	}
+
+	app.setReloadResult(nil)
+
+	slog.Info("Config reloaded")
+}
+
+func (app *App) currentConfig() *config.Config {
+	app.configMutex.RLock()
+	defer app.configMutex.RUnlock()
+
+	return app.config
+}
+
+// restartOnlyServerConfig drops the server settings applied on reload.
+func restartOnlyServerConfig(server config.ServerConfig) config.ServerConfig {
+	server.ControlToken = ""
+	server.Auth = config.AuthConfig{}
+	server.ReadinessMaxMissedChecks = 0
+	server.ShutdownTimeout = 0
+
+	return server
+}
+
+func (app *App) setReloadResult(err error) {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	app.lastReload = time.Now()
+	app.reloadError = err
+}
+
+func modificationTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+func (app *App) runInBackground(run func(ctx context.Context)) {
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+		run(app.ctx)
+	}()
+}
+
+// shutdown stops accepting requests, lets webhooks, checks and their sends
+// finish within the shutdown timeout and flushes the store last.
+func (app *App) shutdown(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), app.currentConfig().Server.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shut down HTTP server: %w", err))
+		}
+	}
+
+	if err := app.monitor.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop monitor: %w", err))
+	}
+
+	if err := wait(ctx, &app.background); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop background tasks: %w", err))
+	}
+
+	if err := app.store.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush store: %w", err))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	slog.Info("Shut down gracefully")
+
+	return nil
+}
+
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"marketplace-notifications/internal/templates"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listTemplates serves the reply templates from the config file and the ones
+// added through the API.
+func (app *App) listTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, app.notifier.ReplyTemplates())
+}
+
+// putTemplate adds or replaces a template, the ones from the config file can
+// only be changed there.
+func (app *App) putTemplate(c *gin.Context) {
+	var template templates.Template
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template: " + err.Error()})
+		return
+	}
+	template.Id = c.Param("id")
+
+	if err := template.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if app.isConfiguredTemplate(template.Id) {
+		c.JSON(http.StatusConflict, gin.H{"error": "template is defined in the config file"})
+		return
+	}
+
+	if err := app.store.SaveTemplate(template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	template.Source = templates.SourceAPI
+	c.JSON(http.StatusOK, template)
+}
+
+func (app *App) deleteTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	if app.isConfiguredTemplate(id) {
+		c.JSON(http.StatusConflict, gin.H{"error": "template is defined in the config file"})
+		return
+	}
+
+	deleted, err := app.store.DeleteTemplate(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "template deleted"})
+}
+
+func (app *App) isConfiguredTemplate(id string) bool {
+	for _, template := range app.notifier.ReplyTemplates() {
+		if template.Id == id && template.Source == templates.SourceConfig {
+			return true
+		}
+	}
+
+	return false
+}
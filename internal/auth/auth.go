@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"marketplace-notifications/internal/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrLockedOut    = errors.New("too many failed attempts, try again later")
+)
+
+type token struct {
+	config.APIToken
+	hash []byte
+}
+
+type failures struct {
+	count       int
+	firstAt     time.Time
+	lockedUntil time.Time
+}
+
+// Authenticator checks bearer tokens against the configured hashes and locks
+// out clients that keep presenting wrong ones.
+type Authenticator struct {
+	mutex    sync.Mutex
+	config   *config.AuthConfig
+	tokens   []token
+	failures map[string]*failures
+	sessions map[string]*session
+}
+
+func NewAuthenticator(config *config.AuthConfig) *Authenticator {
+	authenticator := &Authenticator{
+		failures: make(map[string]*failures),
+		sessions: make(map[string]*session),
+	}
+	authenticator.UpdateConfig(config)
+
+	return authenticator
+}
+
+func (authenticator *Authenticator) UpdateConfig(config *config.AuthConfig) {
+	tokens := make([]token, 0, len(config.Tokens))
+	for _, apiToken := range config.Tokens {
+		hash, _ := hex.DecodeString(apiToken.SHA256)
+		tokens = append(tokens, token{APIToken: apiToken, hash: hash})
+	}
+
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	authenticator.config = config
+	authenticator.tokens = tokens
+}
+
+// Authenticate resolves the "Authorization: Bearer <token>" header of a
+// client to a configured token.
+func (authenticator *Authenticator) Authenticate(clientIP, authorization string) (config.APIToken, error) {
+	presented, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found {
+		presented = ""
+	}
+
+	return authenticator.authenticate(clientIP, presented)
+}
+
+func (authenticator *Authenticator) authenticate(clientIP, presented string) (config.APIToken, error) {
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	now := time.Now()
+
+	if state, ok := authenticator.failures[clientIP]; ok && now.Before(state.lockedUntil) {
+		return config.APIToken{}, ErrLockedOut
+	}
+
+	if strings.TrimSpace(presented) == "" {
+		return config.APIToken{}, ErrMissingToken
+	}
+
+	hash := sha256.Sum256([]byte(strings.TrimSpace(presented)))
+
+	// Every token is compared so that timing doesn't reveal which one is closest.
+	var matched *token
+	for i := range authenticator.tokens {
+		if subtle.ConstantTimeCompare(hash[:], authenticator.tokens[i].hash) == 1 {
+			matched = &authenticator.tokens[i]
+		}
+	}
+
+	if matched == nil {
+		return config.APIToken{}, authenticator.recordFailure(clientIP, now)
+	}
+
+	delete(authenticator.failures, clientIP)
+
+	return matched.APIToken, nil
+}
+
+// recordFailure must be called with the lock held.
+func (authenticator *Authenticator) recordFailure(clientIP string, now time.Time) error {
+	window := authenticator.config.LockoutDuration
+
+	for ip, state := range authenticator.failures {
+		if now.Sub(state.firstAt) > window && now.After(state.lockedUntil) {
+			delete(authenticator.failures, ip)
+		}
+	}
+
+	state, ok := authenticator.failures[clientIP]
+	if !ok {
+		state = &failures{firstAt: now}
+		authenticator.failures[clientIP] = state
+	}
+
+	state.count++
+	if state.count >= authenticator.config.MaxFailures {
+		state.count = 0
+		state.firstAt = now
+		state.lockedUntil = now.Add(window)
+
+		return ErrLockedOut
+	}
+
+	return ErrInvalidToken
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"marketplace-notifications/internal/config"
+	"testing"
+	"time"
+)
+
+// The hashes of "read-token" and "operator-token".
+var testConfig = config.AuthConfig{
+	Tokens: []config.APIToken{
+		{Name: "grafana", Role: config.RoleRead, SHA256: "0328587016f9e316b9c31c94f944a2c453e1494a564062b5eab68039c75b58bf"},
+		{Name: "deploy", Role: config.RoleOperator, SHA256: "0850123315d21ab90f4f7236408a52ef6dbd6a02a6550e5c10dc73f4d993680e"},
+	},
+	MaxFailures:     3,
+	LockoutDuration: time.Minute,
+	SessionTTL:      time.Hour,
+}
+
+func TestAuthenticate(t *testing.T) {
+	authenticator := NewAuthenticator(&testConfig)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantToken     string
+		wantErr       error
+	}{
+		{name: "read token", authorization: "Bearer read-token", wantToken: "grafana"},
+		{name: "operator token", authorization: "Bearer operator-token", wantToken: "deploy"},
+		{name: "no header", authorization: "", wantErr: ErrMissingToken},
+		{name: "not a bearer token", authorization: "Basic read-token", wantErr: ErrMissingToken},
+		{name: "unknown token", authorization: "Bearer other-token", wantErr: ErrInvalidToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := authenticator.Authenticate("10.0.0.1", test.authorization)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if token.Name != test.wantToken {
+				t.Fatalf("got token %q, want %q", token.Name, test.wantToken)
+			}
+		})
+	}
+}
+
+func TestLockout(t *testing.T) {
+	authenticator := NewAuthenticator(&testConfig)
+
+	steps := []struct {
+		clientIP      string
+		authorization string
+		wantErr       error
+	}{
+		{"10.0.0.1", "Bearer wrong", ErrInvalidToken},
+		{"10.0.0.1", "Bearer wrong", ErrInvalidToken},
+		{"10.0.0.1", "Bearer wrong", ErrLockedOut},
+		// Locked out even with a valid token, other clients are not.
+		{"10.0.0.1", "Bearer read-token", ErrLockedOut},
+		{"10.0.0.2", "Bearer read-token", nil},
+		// A success resets the count of failures.
+		{"10.0.0.2", "Bearer wrong", ErrInvalidToken},
+		{"10.0.0.2", "Bearer read-token", nil},
+		{"10.0.0.2", "Bearer wrong", ErrInvalidToken},
+		{"10.0.0.2", "Bearer wrong", ErrInvalidToken},
+	}
+
+	for i, step := range steps {
+		if _, err := authenticator.Authenticate(step.clientIP, step.authorization); !errors.Is(err, step.wantErr) {
+			t.Fatalf("step %d: got error %v, want %v", i+1, err, step.wantErr)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"marketplace-notifications/internal/config"
+	"time"
+)
+
+type session struct {
+	tokenName string
+	csrf      string
+	expiresAt time.Time
+}
+
+// Session is a signed-in browser. CSRF must accompany every form it posts.
+type Session struct {
+	Id    string
+	CSRF  string
+	Token config.APIToken
+}
+
+// Login checks a token typed into the sign-in form, with the same lockout as
+// bearer tokens, and opens a session for it.
+func (authenticator *Authenticator) Login(clientIP, presented string) (Session, error) {
+	token, err := authenticator.authenticate(clientIP, presented)
+	if err != nil {
+		return Session{}, err
+	}
+
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	now := time.Now()
+
+	for id, existing := range authenticator.sessions {
+		if now.After(existing.expiresAt) {
+			delete(authenticator.sessions, id)
+		}
+	}
+
+	id, err := randomHex()
+	if err != nil {
+		return Session{}, err
+	}
+	csrf, err := randomHex()
+	if err != nil {
+		return Session{}, err
+	}
+
+	authenticator.sessions[id] = &session{
+		tokenName: token.Name,
+		csrf:      csrf,
+		expiresAt: now.Add(authenticator.config.SessionTTL),
+	}
+
+	return Session{Id: id, CSRF: csrf, Token: token}, nil
+}
+
+// Session looks up an open session. It ends early when its token is removed
+// from the config, and follows changes to the token's role.
+func (authenticator *Authenticator) Session(id string) (Session, bool) {
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	existing, ok := authenticator.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+
+	if time.Now().After(existing.expiresAt) {
+		delete(authenticator.sessions, id)
+		return Session{}, false
+	}
+
+	for _, token := range authenticator.tokens {
+		if token.Name == existing.tokenName {
+			return Session{Id: id, CSRF: existing.csrf, Token: token.APIToken}, true
+		}
+	}
+
+	delete(authenticator.sessions, id)
+	return Session{}, false
+}
+
+func (authenticator *Authenticator) Logout(id string) {
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	delete(authenticator.sessions, id)
+}
+
+func randomHex() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
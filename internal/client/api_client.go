@@ -1,170 +1,62 @@
 package client
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"marketplace-notifications/internal/config"
-	"marketplace-notifications/internal/marketplaces"
-	"marketplace-notifications/internal/marketplaces/wb"
-	"marketplace-notifications/internal/marketplaces/yandex"
-	"net/http"
-	"net/url"
-	"strconv"
-
-	"golang.org/x/time/rate"
 )
 
 type APIClient struct {
-	config        *config.APIConfig
-	httpClient    *http.Client
-	wbLimiter     *rate.Limiter
-	yandexLimiter *rate.Limiter
+	WB     []*WBClient
+	Yandex []*YandexClient
 }
 
 func NewAPIClient(config *config.APIConfig) *APIClient {
-	wbLimiter := rate.NewLimiter(rate.Limit(config.WB.RPS), config.WB.Burst)
-	yandexLimiter := rate.NewLimiter(rate.Limit(config.Yandex.RPS), config.Yandex.Burst)
+	apiClient := &APIClient{}
 
-	return &APIClient{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
-		wbLimiter:     wbLimiter,
-		yandexLimiter: yandexLimiter,
+	for _, account := range config.WB {
+		apiClient.WB = append(apiClient.WB, NewWBClient(account, config.Timeout))
 	}
-}
 
-func (client *APIClient) FetchWBQuestions() ([]wb.Question, error) {
-	jsonData, err := client.FetchWBData(marketplaces.Question)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch questions: %w", err)
+	for _, account := range config.Yandex {
+		apiClient.Yandex = append(apiClient.Yandex, NewYandexClient(account, config.Timeout))
 	}
 
-	var questionsResponse wb.QuestionsResponse
-	if err := json.Unmarshal(jsonData, &questionsResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal questions response: %w", err)
-	}
-
-	return questionsResponse.Data.Questions, nil
+	return apiClient
 }
 
-func (client *APIClient) FetchWBFeedbacks() ([]wb.Feedback, error) {
-	jsonData, err := client.FetchWBData(marketplaces.Feedback)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch feedbacks: %w", err)
+func (apiClient *APIClient) WBClientByName(name string) (*WBClient, bool) {
+	for _, client := range apiClient.WB {
+		if client.Name() == name {
+			return client, true
+		}
 	}
 
-	var feedbacksResponse wb.FeedbacksResponse
-	if err := json.Unmarshal(jsonData, &feedbacksResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal feedbacks response: %w", err)
-	}
-
-	return feedbacksResponse.Data.Feedbacks, nil
+	return nil, false
 }
 
-func (client *APIClient) FetchWBData(reactionType marketplaces.UserReactionType) ([]byte, error) {
-	if err := client.wbLimiter.Wait(context.Background()); err != nil {
-		return nil, fmt.Errorf("WB rate limiter error: %w", err)
-	}
-
-	var urlToParse string
-	var maxNewReactions int
-	if reactionType == marketplaces.Question {
-		urlToParse = client.config.WB.QuestionsURL()
-		maxNewReactions = client.config.WB.MaxNewQuestions
-	} else {
-		urlToParse = client.config.WB.FeedbacksURL()
-		maxNewReactions = client.config.WB.MaxNewFeedbacks
-	}
-
-	baseURL, err := url.Parse(urlToParse)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
-
-	query := url.Values{}
-
-	query.Set("isAnswered", strconv.FormatBool(false))
-	query.Set("take", strconv.Itoa(maxNewReactions))
-	query.Set("skip", strconv.Itoa(0))
-
-	baseURL.RawQuery = query.Encode()
-
-	fullURL := baseURL.String()
-
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("content-type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.config.WB.JWT))
-
-	resp, err := client.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d instead of 200: %s", resp.StatusCode, body)
+func (apiClient *APIClient) YandexClientByName(name string) (*YandexClient, bool) {
+	for _, client := range apiClient.Yandex {
+		if client.Name() == name {
+			return client, true
+		}
 	}
 
-	return body, nil
+	return nil, false
 }
 
-func (client *APIClient) FetchYandexFeedback(businessId, feedbackId int, feedback *yandex.Feedback) error {
-	if err := client.yandexLimiter.Wait(context.Background()); err != nil {
-		return fmt.Errorf("WB rate limiter error: %w", err)
-	}
-
-	reqBody := map[string]any{"feedbackIds": []int{feedbackId}}
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("error marshalling JSON: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", client.config.Yandex.FeedbacksURL(businessId), bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("content-type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.config.Yandex.APIToken))
+// YandexClientForBusiness picks the account configured for the business,
+// falling back to an account without a business id.
+func (apiClient *APIClient) YandexClientForBusiness(businessId int) (*YandexClient, bool) {
+	var fallback *YandexClient
 
-	resp, err := client.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d instead of 200: %s", resp.StatusCode, respBody)
-	}
-
-	var feedbacksResponse yandex.FeedbacksResponse
-	if err := json.Unmarshal(respBody, &feedbacksResponse); err != nil {
-		return fmt.Errorf("failed to parse Yandex feedback response: %w", err)
-	}
+	for _, client := range apiClient.Yandex {
+		if client.config.BusinessId == businessId {
+			return client, true
+		}
 
-	if len(feedbacksResponse.Result.Feedbacks) == 0 {
-		return fmt.Errorf("unable to fetch Yandex feedback with id %d", feedbackId)
+		if client.config.BusinessId == 0 && fallback == nil {
+			fallback = client
+		}
 	}
 
-	*feedback = feedbacksResponse.Result.Feedbacks[0]
-	return nil
+	return fallback, fallback != nil
 }
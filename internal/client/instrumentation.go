@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"marketplace-notifications/internal/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func waitForLimiter(limiter *rate.Limiter, name string) error {
+	start := time.Now()
+	err := limiter.Wait(context.Background())
+	metrics.RateLimiterWait.Observe(time.Since(start).Seconds(), name)
+
+	return err
+}
+
+func doInstrumented(httpClient *http.Client, req *http.Request, marketplace, endpoint string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	metrics.MarketplaceRequestDuration.Observe(time.Since(start).Seconds(), marketplace, endpoint)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.MarketplaceRequests.Inc(marketplace, endpoint, status)
+
+	return resp, err
+}
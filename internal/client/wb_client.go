@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type WBClient struct {
+	config     wb.Config
+	httpClient *http.Client
+	limiter    *rate.Limiter
+}
+
+func NewWBClient(config wb.Config, timeout time.Duration) *WBClient {
+	return &WBClient{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		limiter: rate.NewLimiter(rate.Limit(config.RPS), config.Burst),
+	}
+}
+
+func (client *WBClient) Name() string {
+	return client.config.Name
+}
+
+func (client *WBClient) TokenInfo() (wb.TokenInfo, error) {
+	return wb.ParseToken(client.config.JWT)
+}
+
+// TokenFingerprint tells tokens apart without revealing them.
+func (client *WBClient) TokenFingerprint() string {
+	sum := sha256.Sum256([]byte(client.config.JWT))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (client *WBClient) FetchQuestions() ([]wb.Question, error) {
+	return client.fetchQuestions(false)
+}
+
+func (client *WBClient) FetchAnsweredQuestions() ([]wb.Question, error) {
+	return client.fetchQuestions(true)
+}
+
+func (client *WBClient) FetchFeedbacks() ([]wb.Feedback, error) {
+	return client.fetchFeedbacks(false)
+}
+
+func (client *WBClient) FetchAnsweredFeedbacks() ([]wb.Feedback, error) {
+	return client.fetchFeedbacks(true)
+}
+
+func (client *WBClient) fetchQuestions(isAnswered bool) ([]wb.Question, error) {
+	jsonData, err := client.FetchData(marketplaces.Question, isAnswered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch questions: %w", err)
+	}
+
+	var questionsResponse wb.QuestionsResponse
+	if err := json.Unmarshal(jsonData, &questionsResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal questions response: %w", err)
+	}
+
+	return questionsResponse.Data.Questions, nil
+}
+
+func (client *WBClient) fetchFeedbacks(isAnswered bool) ([]wb.Feedback, error) {
+	jsonData, err := client.FetchData(marketplaces.Feedback, isAnswered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feedbacks: %w", err)
+	}
+
+	var feedbacksResponse wb.FeedbacksResponse
+	if err := json.Unmarshal(jsonData, &feedbacksResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal feedbacks response: %w", err)
+	}
+
+	return feedbacksResponse.Data.Feedbacks, nil
+}
+
+func (client *WBClient) FetchLimit(reactionType marketplaces.UserReactionType) int {
+	if reactionType == marketplaces.Question {
+		return client.config.MaxNewQuestions
+	}
+
+	return client.config.MaxNewFeedbacks
+}
+
+// AnswerFeedback publishes the answer to a feedback.
+func (client *WBClient) AnswerFeedback(feedbackId, text string) error {
+	return client.sendAnswer(http.MethodPost, client.config.FeedbackAnswerURL(), marketplaces.Feedback, map[string]any{
+		"id":   feedbackId,
+		"text": text,
+	})
+}
+
+// AnswerQuestion publishes the answer to a question.
+func (client *WBClient) AnswerQuestion(questionId, text string) error {
+	return client.sendAnswer(http.MethodPatch, client.config.QuestionsURL(), marketplaces.Question, map[string]any{
+		"id":     questionId,
+		"answer": map[string]string{"text": text},
+		"state":  "wbRu",
+	})
+}
+
+func (client *WBClient) sendAnswer(method, reqURL string, reactionType marketplaces.UserReactionType, reqBody map[string]any) error {
+	if err := waitForLimiter(client.limiter, "WB"); err != nil {
+		return fmt.Errorf("WB rate limiter error: %w", err)
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	req, err := http.NewRequest(method, reqURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.config.JWT))
+
+	resp, err := doInstrumented(client.httpClient, req, "WB", reactionType.String()+"_answer")
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+func (client *WBClient) FetchData(reactionType marketplaces.UserReactionType, isAnswered bool) ([]byte, error) {
+	if err := waitForLimiter(client.limiter, "WB"); err != nil {
+		return nil, fmt.Errorf("WB rate limiter error: %w", err)
+	}
+
+	var urlToParse string
+	if reactionType == marketplaces.Question {
+		urlToParse = client.config.QuestionsURL()
+	} else {
+		urlToParse = client.config.FeedbacksURL()
+	}
+	maxNewReactions := client.FetchLimit(reactionType)
+
+	baseURL, err := url.Parse(urlToParse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	query := url.Values{}
+
+	query.Set("isAnswered", strconv.FormatBool(isAnswered))
+	query.Set("take", strconv.Itoa(maxNewReactions))
+	query.Set("skip", strconv.Itoa(0))
+
+	if isAnswered {
+		query.Set("order", "dateDesc")
+	}
+
+	baseURL.RawQuery = query.Encode()
+
+	fullURL := baseURL.String()
+
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.config.JWT))
+
+	resp, err := doInstrumented(client.httpClient, req, "WB", reactionType.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d instead of 200: %s", resp.StatusCode, body)
+	}
+
+	return body, nil
+}
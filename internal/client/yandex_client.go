@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	feedbacksPageSize = 50
+	maxFeedbackPages  = 100
+)
+
+type YandexClient struct {
+	config     yandex.Config
+	httpClient *http.Client
+	limiter    *rate.Limiter
+}
+
+func NewYandexClient(config yandex.Config, timeout time.Duration) *YandexClient {
+	return &YandexClient{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		limiter: rate.NewLimiter(rate.Limit(config.RPS), config.Burst),
+	}
+}
+
+func (client *YandexClient) Name() string {
+	return client.config.Name
+}
+
+// BusinessId is the business the account is configured for, zero if none.
+func (client *YandexClient) BusinessId() int {
+	return client.config.BusinessId
+}
+
+func (client *YandexClient) FetchFeedback(businessId, feedbackId int, feedback *yandex.Feedback) error {
+	feedbacksResponse, err := client.postFeedbacks(businessId, nil, map[string]any{"feedbackIds": []int{feedbackId}})
+	if err != nil {
+		return err
+	}
+
+	if len(feedbacksResponse.Result.Feedbacks) == 0 {
+		return fmt.Errorf("unable to fetch Yandex feedback with id %d", feedbackId)
+	}
+
+	*feedback = feedbacksResponse.Result.Feedbacks[0]
+	return nil
+}
+
+// FetchFeedbacks fetches the feedbacks with the given ids, the ones that no
+// longer exist are left out.
+func (client *YandexClient) FetchFeedbacks(businessId int, feedbackIds []int) ([]yandex.Feedback, error) {
+	var feedbacks []yandex.Feedback
+
+	for batch := range slices.Chunk(feedbackIds, feedbacksPageSize) {
+		feedbacksResponse, err := client.postFeedbacks(businessId, nil, map[string]any{"feedbackIds": batch})
+		if err != nil {
+			return nil, err
+		}
+
+		feedbacks = append(feedbacks, feedbacksResponse.Result.Feedbacks...)
+	}
+
+	return feedbacks, nil
+}
+
+// ListFeedbacks pages through the feedbacks of the business created since from.
+func (client *YandexClient) ListFeedbacks(businessId int, from time.Time) ([]yandex.Feedback, error) {
+	var feedbacks []yandex.Feedback
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(feedbacksPageSize))
+
+	for page := 0; page < maxFeedbackPages; page++ {
+		feedbacksResponse, err := client.postFeedbacks(businessId, query, map[string]any{"dateTimeFrom": from.Format(time.RFC3339)})
+		if err != nil {
+			return nil, err
+		}
+
+		feedbacks = append(feedbacks, feedbacksResponse.Result.Feedbacks...)
+
+		nextPageToken := feedbacksResponse.Result.Paging.NextPageToken
+		if nextPageToken == "" || len(feedbacksResponse.Result.Feedbacks) == 0 {
+			return feedbacks, nil
+		}
+
+		query.Set("page_token", nextPageToken)
+	}
+
+	return nil, fmt.Errorf("Yandex feedback listing has more than %d pages", maxFeedbackPages)
+}
+
+// AnswerFeedback publishes a comment of the business answering the feedback.
+func (client *YandexClient) AnswerFeedback(businessId, feedbackId int, text string) error {
+	if err := waitForLimiter(client.limiter, "Yandex"); err != nil {
+		return fmt.Errorf("Yandex rate limiter error: %w", err)
+	}
+
+	jsonData, err := json.Marshal(map[string]any{
+		"feedbackId": feedbackId,
+		"comment":    map[string]string{"text": text},
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", client.config.FeedbackCommentURL(businessId), bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.config.APIToken))
+
+	resp, err := doInstrumented(client.httpClient, req, "Yandex", "feedbacks_answer")
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d instead of 200: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+func (client *YandexClient) postFeedbacks(businessId int, query url.Values, reqBody map[string]any) (*yandex.FeedbacksResponse, error) {
+	if err := waitForLimiter(client.limiter, "Yandex"); err != nil {
+		return nil, fmt.Errorf("Yandex rate limiter error: %w", err)
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling JSON: %w", err)
+	}
+
+	reqURL := client.config.FeedbacksURL(businessId)
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.config.APIToken))
+
+	resp, err := doInstrumented(client.httpClient, req, "Yandex", "feedbacks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d instead of 200: %s", resp.StatusCode, respBody)
+	}
+
+	var feedbacksResponse yandex.FeedbacksResponse
+	if err := json.Unmarshal(respBody, &feedbacksResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse Yandex feedback response: %w", err)
+	}
+
+	return &feedbacksResponse, nil
+}
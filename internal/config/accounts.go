@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"strconv"
+	"strings"
+)
+
+// parseWBAccounts parses entries of the form "label:jwt". Errors name the
+// entry by its position, its text may be a token.
+func parseWBAccounts(entries []string) ([]wb.Config, error) {
+	accounts := make([]wb.Config, 0, len(entries))
+
+	for i, entry := range entries {
+		name, JWT, found := strings.Cut(entry, ":")
+		if !found || strings.TrimSpace(name) == "" || strings.TrimSpace(JWT) == "" {
+			return nil, fmt.Errorf("invalid WB account #%d, expected label:jwt", i+1)
+		}
+
+		accounts = append(accounts, wb.Config{Name: strings.TrimSpace(name), JWT: strings.TrimSpace(JWT)})
+	}
+
+	return accounts, nil
+}
+
+// parseYandexAccounts parses entries of the form "label:businessId:token".
+// Errors name the entry by its position, its text may be a token.
+func parseYandexAccounts(entries []string) ([]yandex.Config, error) {
+	accounts := make([]yandex.Config, 0, len(entries))
+
+	for i, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[2]) == "" {
+			return nil, fmt.Errorf("invalid Yandex account #%d, expected label:businessId:token", i+1)
+		}
+
+		businessId, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || businessId <= 0 {
+			return nil, fmt.Errorf("invalid business id of Yandex account #%d", i+1)
+		}
+
+		accounts = append(accounts, yandex.Config{Name: strings.TrimSpace(parts[0]), APIToken: strings.TrimSpace(parts[2]), BusinessId: businessId})
+	}
+
+	return accounts, nil
+}
+
+func validateAccountNames(marketplace string, names []string) error {
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		if seen[name] {
+			return fmt.Errorf("duplicate %s account %q", marketplace, name)
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"marketplace-notifications/internal/utils/env"
+	"strings"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleRead     Role = "read"
+	RoleOperator Role = "operator"
+)
+
+// Allows reports whether the role may do what the required role may.
+// Operators can also read.
+func (role Role) Allows(required Role) bool {
+	return role == required || role == RoleOperator
+}
+
+// AuthConfig lists the API tokens. Signing in to the dashboard with one of
+// them lasts SessionTTL.
+type AuthConfig struct {
+	Tokens          []APIToken    `yaml:"tokens"`
+	MaxFailures     int           `yaml:"maxFailures"`
+	LockoutDuration time.Duration `yaml:"lockoutDuration"`
+	SessionTTL      time.Duration `yaml:"sessionTTL"`
+}
+
+// APIToken is a named API token. Only the hex-encoded SHA-256 of the token is
+// kept in the config.
+type APIToken struct {
+	Name   string `yaml:"name"`
+	Role   Role   `yaml:"role"`
+	SHA256 string `yaml:"sha256"`
+}
+
+func (config *AuthConfig) loadEnv() error {
+	if entries := env.GetEnvStringSlice("API_TOKENS", nil); entries != nil {
+		tokens, err := parseAPITokens(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing API_TOKENS: %w", err)
+		}
+		config.Tokens = tokens
+	}
+
+	config.MaxFailures = env.GetEnvInt("AUTH_MAX_FAILURES", config.MaxFailures)
+	config.LockoutDuration = env.GetEnvDuration("AUTH_LOCKOUT_DURATION", config.LockoutDuration)
+	config.SessionTTL = env.GetEnvDuration("AUTH_SESSION_TTL", config.SessionTTL)
+
+	return nil
+}
+
+// parseAPITokens parses entries of the form "name:role:sha256".
+func parseAPITokens(entries []string) ([]APIToken, error) {
+	tokens := make([]APIToken, 0, len(entries))
+
+	for i, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid API token #%d, expected name:role:sha256", i+1)
+		}
+
+		tokens = append(tokens, APIToken{
+			Name:   strings.TrimSpace(parts[0]),
+			Role:   Role(strings.TrimSpace(parts[1])),
+			SHA256: strings.ToLower(strings.TrimSpace(parts[2])),
+		})
+	}
+
+	return tokens, nil
+}
+
+func (config *AuthConfig) validate() error {
+	if len(config.Tokens) == 0 {
+		return fmt.Errorf("missing API_TOKENS or CONTROL_TOKEN")
+	}
+
+	names := make(map[string]bool, len(config.Tokens))
+	for _, token := range config.Tokens {
+		if token.Name == "" {
+			return fmt.Errorf("API token without a name")
+		}
+		if names[token.Name] {
+			return fmt.Errorf("duplicate API token name %q", token.Name)
+		}
+		names[token.Name] = true
+
+		if token.Role != RoleRead && token.Role != RoleOperator {
+			return fmt.Errorf("API token %s has unknown role %q, expected read or operator", token.Name, token.Role)
+		}
+
+		if hash, err := hex.DecodeString(token.SHA256); err != nil || len(hash) != 32 {
+			return fmt.Errorf("API token %s must be a hex-encoded SHA-256 hash", token.Name)
+		}
+	}
+
+	if config.MaxFailures <= 0 {
+		return fmt.Errorf("auth max failures must be positive")
+	}
+	if config.LockoutDuration <= 0 {
+		return fmt.Errorf("auth lockout duration must be positive")
+	}
+	if config.SessionTTL <= 0 {
+		return fmt.Errorf("auth session TTL must be positive")
+	}
+
+	return nil
+}
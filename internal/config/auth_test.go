@@ -0,0 +1,153 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTokenHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestParseAPITokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    []APIToken
+		wantErr string
+	}{
+		{
+			name:    "single token",
+			entries: []string{"grafana:read:" + testTokenHash},
+			want:    []APIToken{{Name: "grafana", Role: RoleRead, SHA256: testTokenHash}},
+		},
+		{
+			name:    "spaces are trimmed and the hash lowercased",
+			entries: []string{" deploy : operator : " + strings.ToUpper(testTokenHash)},
+			want:    []APIToken{{Name: "deploy", Role: RoleOperator, SHA256: testTokenHash}},
+		},
+		{
+			name:    "several tokens",
+			entries: []string{"a:read:" + testTokenHash, "b:operator:" + testTokenHash},
+			want: []APIToken{
+				{Name: "a", Role: RoleRead, SHA256: testTokenHash},
+				{Name: "b", Role: RoleOperator, SHA256: testTokenHash},
+			},
+		},
+		{
+			name:    "missing hash",
+			entries: []string{"a:read:" + testTokenHash, "secret-token:read"},
+			wantErr: "invalid API token #2, expected name:role:sha256",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := parseAPITokens(test.entries)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				if strings.Contains(err.Error(), "secret-token") {
+					t.Fatalf("error %q reveals the entry", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tokens, test.want) {
+				t.Fatalf("got %+v, want %+v", tokens, test.want)
+			}
+		})
+	}
+}
+
+func TestAuthConfigValidate(t *testing.T) {
+	valid := func() AuthConfig {
+		return AuthConfig{
+			Tokens:          []APIToken{{Name: "grafana", Role: RoleRead, SHA256: testTokenHash}},
+			MaxFailures:     5,
+			LockoutDuration: 15 * time.Minute,
+			SessionTTL:      12 * time.Hour,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(config *AuthConfig)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(config *AuthConfig) {},
+		},
+		{
+			name:    "no tokens",
+			modify:  func(config *AuthConfig) { config.Tokens = nil },
+			wantErr: "missing API_TOKENS or CONTROL_TOKEN",
+		},
+		{
+			name:    "token without a name",
+			modify:  func(config *AuthConfig) { config.Tokens[0].Name = "" },
+			wantErr: "API token without a name",
+		},
+		{
+			name: "duplicate names",
+			modify: func(config *AuthConfig) {
+				config.Tokens = append(config.Tokens, APIToken{Name: "grafana", Role: RoleOperator, SHA256: testTokenHash})
+			},
+			wantErr: `duplicate API token name "grafana"`,
+		},
+		{
+			name:    "unknown role",
+			modify:  func(config *AuthConfig) { config.Tokens[0].Role = "admin" },
+			wantErr: `API token grafana has unknown role "admin", expected read or operator`,
+		},
+		{
+			name:    "hash of the wrong length",
+			modify:  func(config *AuthConfig) { config.Tokens[0].SHA256 = "abcd" },
+			wantErr: "API token grafana must be a hex-encoded SHA-256 hash",
+		},
+		{
+			name:    "no lockout",
+			modify:  func(config *AuthConfig) { config.MaxFailures = 0 },
+			wantErr: "auth max failures must be positive",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := valid()
+			test.modify(&config)
+
+			err := config.validate()
+			switch {
+			case test.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.wantErr != "" && (err == nil || err.Error() != test.wantErr):
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		want     bool
+	}{
+		{RoleRead, RoleRead, true},
+		{RoleRead, RoleOperator, false},
+		{RoleOperator, RoleRead, true},
+		{RoleOperator, RoleOperator, true},
+	}
+
+	for _, test := range tests {
+		if got := test.role.Allows(test.required); got != test.want {
+			t.Errorf("%s.Allows(%s) = %v, want %v", test.role, test.required, got, test.want)
+		}
+	}
+}
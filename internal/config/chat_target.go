@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultTopic         = "default"
+	WBQuestionsTopic     = "wb_questions"
+	WBFeedbacksTopic     = "wb_feedbacks"
+	YandexFeedbacksTopic = "yandex_feedbacks"
+)
+
+var knownTopics = []string{DefaultTopic, WBQuestionsTopic, WBFeedbacksTopic, YandexFeedbacksTopic}
+
+type ChatTarget struct {
+	ChatId  string         `json:"chatId" yaml:"chatId"`
+	Forum   bool           `json:"forum,omitempty" yaml:"forum"`
+	Threads map[string]int `json:"threads,omitempty" yaml:"threads"`
+}
+
+func (target ChatTarget) IsForum() bool {
+	return target.Forum || len(target.Threads) > 0
+}
+
+func (target ChatTarget) ThreadId(topic string) (int, bool) {
+	threadId, ok := target.Threads[topic]
+	return threadId, ok
+}
+
+// parseChatTargets parses entries of the form "chatId|topic=threadId|...",
+// e.g. "-1001234567890|wb_questions=12|wb_feedbacks=13|yandex_feedbacks=14".
+// A bare "forum" part marks a forum chat without any preconfigured threads.
+func parseChatTargets(entries []string) ([]ChatTarget, error) {
+	targets := make([]ChatTarget, 0, len(entries))
+
+	for _, entry := range entries {
+		parts := strings.Split(entry, "|")
+
+		target := ChatTarget{
+			ChatId:  strings.TrimSpace(parts[0]),
+			Threads: make(map[string]int),
+		}
+		if target.ChatId == "" {
+			return nil, fmt.Errorf("missing chat id in %q", entry)
+		}
+
+		for _, part := range parts[1:] {
+			if strings.TrimSpace(part) == "forum" {
+				target.Forum = true
+				continue
+			}
+
+			topic, rawThreadId, found := strings.Cut(strings.TrimSpace(part), "=")
+			if !found {
+				return nil, fmt.Errorf("invalid topic route %q for chat %s", part, target.ChatId)
+			}
+
+			if !isKnownTopic(topic) {
+				return nil, fmt.Errorf("unknown topic %q for chat %s", topic, target.ChatId)
+			}
+
+			threadId, err := strconv.Atoi(rawThreadId)
+			if err != nil || threadId <= 0 {
+				return nil, fmt.Errorf("invalid thread id %q for topic %s in chat %s", rawThreadId, topic, target.ChatId)
+			}
+
+			target.Threads[topic] = threadId
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+func isKnownTopic(topic string) bool {
+	for _, knownTopic := range knownTopics {
+		if topic == knownTopic {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateChatTargets(chats []ChatTarget) error {
+	for _, chat := range chats {
+		if chat.ChatId == "" {
+			return fmt.Errorf("missing chat id of a Telegram chat")
+		}
+
+		for topic, threadId := range chat.Threads {
+			if !isKnownTopic(topic) {
+				return fmt.Errorf("unknown topic %q for chat %s", topic, chat.ChatId)
+			}
+			if threadId <= 0 {
+				return fmt.Errorf("invalid thread id %d for topic %s in chat %s", threadId, topic, chat.ChatId)
+			}
+		}
+	}
+
+	return nil
+}
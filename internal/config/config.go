@@ -1,88 +1,408 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"marketplace-notifications/internal/logging"
 	"marketplace-notifications/internal/marketplaces/wb"
 	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/templates"
 	"marketplace-notifications/internal/utils/env"
+	"marketplace-notifications/internal/utils/ip"
+	"os"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Monitor  MonitorConfig
-	API      APIConfig
-	Telegram TelegramConfig
+	Server   ServerConfig   `yaml:"server"`
+	Monitor  MonitorConfig  `yaml:"monitor"`
+	API      APIConfig      `yaml:"api"`
+	Telegram TelegramConfig `yaml:"telegram"`
+	Store    StoreConfig    `yaml:"store"`
+	Reload   ReloadConfig   `yaml:"reload"`
+	Log      LogConfig      `yaml:"log"`
 }
 
 type ServerConfig struct {
-	ControlToken string
-	Port         int
+	ControlToken             string        `yaml:"controlToken"`
+	Port                     int           `yaml:"port"`
+	ReadinessMaxMissedChecks int           `yaml:"readinessMaxMissedChecks"`
+	ShutdownTimeout          time.Duration `yaml:"shutdownTimeout"`
+	Auth                     AuthConfig    `yaml:"auth"`
+	TrustedProxies           []string      `yaml:"trustedProxies"`
+	YandexAllowedIPs         []string      `yaml:"yandexAllowedIps"`
 }
 
 type MonitorConfig struct {
-	CheckInterval time.Duration
+	Autostart     bool                 `yaml:"autostart"`
+	CheckInterval time.Duration        `yaml:"checkInterval"`
+	WB            WBScheduleConfig     `yaml:"wb"`
+	Yandex        YandexScheduleConfig `yaml:"yandex"`
+	TokenCheck    TokenCheckConfig     `yaml:"tokenCheck"`
+	Report        ReportConfig         `yaml:"report"`
+}
+
+type TokenCheckConfig struct {
+	Interval  time.Duration `yaml:"interval"`
+	AlertDays []int         `yaml:"alertDays"`
 }
 
 type APIConfig struct {
-	WB      wb.Config
-	Yandex  yandex.Config
-	Timeout time.Duration
+	WB              []wb.Config     `yaml:"wb"`
+	Yandex          []yandex.Config `yaml:"yandex"`
+	Timeout         time.Duration   `yaml:"timeout"`
+	MaxNewQuestions int             `yaml:"maxNewQuestions"`
+	MaxNewFeedbacks int             `yaml:"maxNewFeedbacks"`
 }
 
 type TelegramConfig struct {
-	BotToken string
-	ChatIds  []string
-	Timeout  time.Duration
-	RPS      int
+	BotToken          string               `yaml:"botToken"`
+	Chats             []ChatTarget         `yaml:"chats"`
+	AdminIds          []string             `yaml:"adminIds"`
+	CreateForumTopics bool                 `yaml:"createForumTopics"`
+	Timeout           time.Duration        `yaml:"timeout"`
+	UpdatesTimeout    time.Duration        `yaml:"updatesTimeout"`
+	RPS               int                  `yaml:"rps"`
+	NegativeReviews   NegativeReviewConfig `yaml:"negativeReviews"`
+	Tagging           TaggingConfig        `yaml:"tagging"`
+	ReplyTemplates    []templates.Template `yaml:"replyTemplates"`
+}
+
+type StoreConfig struct {
+	Path string `yaml:"path"`
+}
+
+type ReloadConfig struct {
+	WatchInterval time.Duration `yaml:"watchInterval"`
 }
 
-func Load() (*Config, error) {
-	config := &Config{
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Secrets lists every credential in the config so that it can be redacted
+// from logs.
+func (config *Config) Secrets() []string {
+	secrets := []string{config.Server.ControlToken, config.Telegram.BotToken}
+
+	for _, account := range config.API.WB {
+		secrets = append(secrets, account.JWT)
+	}
+	for _, account := range config.API.Yandex {
+		secrets = append(secrets, account.APIToken)
+	}
+
+	return secrets
+}
+
+// Load builds the config from defaults, the optional YAML file at path and
+// environment variables, in increasing order of precedence.
+func Load(path string) (*Config, error) {
+	config := defaultConfig()
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, fmt.Errorf("error loading config file: %w", err)
+		}
+	}
+
+	if err := config.loadEnv(); err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	config.applyAccountDefaults()
+	config.applyControlToken()
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() *Config {
+	return &Config{
 		Server: ServerConfig{
-			Port:         env.GetEnvInt("SERVER_PORT", 8080),
-			ControlToken: env.GetEnv("CONTROL_TOKEN", ""),
+			Port:                     8080,
+			ReadinessMaxMissedChecks: 3,
+			ShutdownTimeout:          30 * time.Second,
+			YandexAllowedIPs:         yandex.IPWhitelist,
+			Auth: AuthConfig{
+				MaxFailures:     5,
+				LockoutDuration: 15 * time.Minute,
+				SessionTTL:      12 * time.Hour,
+			},
 		},
 		Monitor: MonitorConfig{
-			CheckInterval: env.GetEnvDuration("CHECK_INTERVAL", 2*time.Minute),
+			CheckInterval: 2 * time.Minute,
+			WB: WBScheduleConfig{
+				Enabled:   true,
+				Questions: defaultSourceConfig(),
+				Feedbacks: defaultSourceConfig(),
+			},
+			Yandex: YandexScheduleConfig{
+				Enabled:   true,
+				Mode:      YandexWebhookMode,
+				Feedbacks: defaultSourceConfig(),
+				Webhook: YandexWebhookConfig{
+					Workers:          2,
+					MaxAttempts:      5,
+					RetryBackoff:     10 * time.Second,
+					SummaryThreshold: 10,
+				},
+				Reconcile: YandexReconcileConfig{
+					Enabled:  true,
+					Interval: 30 * time.Minute,
+					Lookback: 24 * time.Hour,
+				},
+			},
+			TokenCheck: TokenCheckConfig{
+				Interval:  24 * time.Hour,
+				AlertDays: []int{14, 7, 1},
+			},
+			Report: ReportConfig{
+				Time:    "09:00",
+				Weekday: "monday",
+			},
 		},
 		API: APIConfig{
-			WB:      wb.GetConfig(env.GetEnv("WB_JWT", ""), env.GetEnvInt("MAX_NEW_QUESTIONS_TO_FETCH", 20), env.GetEnvInt("MAX_NEW_FEEDBACKS_TO_FETCH", 20)),
-			Yandex:  yandex.GetConfig(env.GetEnv("YANDEX_TOKEN", "")),
-			Timeout: env.GetEnvDuration("MARKETPLACE_API_TIMEOUT", 30*time.Second),
+			Timeout:         30 * time.Second,
+			MaxNewQuestions: 20,
+			MaxNewFeedbacks: 20,
 		},
 		Telegram: TelegramConfig{
-			BotToken: env.GetEnv("TELEGRAM_BOT_TOKEN", ""),
-			ChatIds:  env.GetEnvStringSlice("TELEGRAM_CHAT_IDS", nil),
-			Timeout:  env.GetEnvDuration("TELEGRAM_API_TIMEOUT", 30*time.Second),
-			RPS:      1,
+			Timeout:        30 * time.Second,
+			UpdatesTimeout: 25 * time.Second,
+			RPS:            1,
+			NegativeReviews: NegativeReviewConfig{
+				MaxStars:       2,
+				NotRecommended: true,
+				Pin:            true,
+			},
+		},
+		Store: StoreConfig{
+			Path: "data/store.json",
+		},
+		Reload: ReloadConfig{
+			WatchInterval: 10 * time.Second,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
 		},
 	}
+}
 
-	if err := config.validate(); err != nil {
-		return nil, fmt.Errorf("error loading config: %w", err)
+func (config *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	return config, nil
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return nil
 }
 
-func (config *Config) validate() error {
+func (config *Config) loadEnv() error {
+	config.Server.Port = env.GetEnvInt("SERVER_PORT", config.Server.Port)
+	config.Server.ControlToken = env.GetEnv("CONTROL_TOKEN", config.Server.ControlToken)
+	config.Server.ReadinessMaxMissedChecks = env.GetEnvInt("READINESS_MAX_MISSED_CHECKS", config.Server.ReadinessMaxMissedChecks)
+	config.Server.ShutdownTimeout = env.GetEnvDuration("SHUTDOWN_TIMEOUT", config.Server.ShutdownTimeout)
+	config.Server.TrustedProxies = env.GetEnvStringSlice("TRUSTED_PROXIES", config.Server.TrustedProxies)
+	config.Server.YandexAllowedIPs = env.GetEnvStringSlice("YANDEX_ALLOWED_IPS", config.Server.YandexAllowedIPs)
+	if err := config.Server.Auth.loadEnv(); err != nil {
+		return err
+	}
+
+	if err := config.Monitor.loadEnv(); err != nil {
+		return err
+	}
+
+	config.API.Timeout = env.GetEnvDuration("MARKETPLACE_API_TIMEOUT", config.API.Timeout)
+	config.API.MaxNewQuestions = env.GetEnvInt("MAX_NEW_QUESTIONS_TO_FETCH", config.API.MaxNewQuestions)
+	config.API.MaxNewFeedbacks = env.GetEnvInt("MAX_NEW_FEEDBACKS_TO_FETCH", config.API.MaxNewFeedbacks)
+
+	if entries := env.GetEnvStringSlice("WB_ACCOUNTS", nil); entries != nil {
+		wbAccounts, err := parseWBAccounts(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing WB_ACCOUNTS: %w", err)
+		}
+		config.API.WB = wbAccounts
+	}
+	if JWT := env.GetEnv("WB_JWT", ""); JWT != "" {
+		config.API.WB = append(config.API.WB, wb.Config{Name: "WB", JWT: JWT})
+	}
+
+	if entries := env.GetEnvStringSlice("YANDEX_ACCOUNTS", nil); entries != nil {
+		yandexAccounts, err := parseYandexAccounts(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing YANDEX_ACCOUNTS: %w", err)
+		}
+		config.API.Yandex = yandexAccounts
+	}
+	if APIToken := env.GetEnv("YANDEX_TOKEN", ""); APIToken != "" {
+		config.API.Yandex = append(config.API.Yandex, yandex.Config{Name: "Yandex", APIToken: APIToken})
+	}
+
+	config.Telegram.BotToken = env.GetEnv("TELEGRAM_BOT_TOKEN", config.Telegram.BotToken)
+	config.Telegram.AdminIds = env.GetEnvStringSlice("TELEGRAM_ADMIN_IDS", config.Telegram.AdminIds)
+	config.Telegram.CreateForumTopics = env.GetEnvBool("TELEGRAM_CREATE_FORUM_TOPICS", config.Telegram.CreateForumTopics)
+	config.Telegram.Timeout = env.GetEnvDuration("TELEGRAM_API_TIMEOUT", config.Telegram.Timeout)
+	config.Telegram.UpdatesTimeout = env.GetEnvDuration("TELEGRAM_UPDATES_TIMEOUT", config.Telegram.UpdatesTimeout)
+	config.Telegram.NegativeReviews.loadEnv()
+	if err := config.Telegram.Tagging.loadEnv(); err != nil {
+		return err
+	}
+
+	if entries := env.GetEnvStringSlice("TELEGRAM_CHAT_IDS", nil); entries != nil {
+		chats, err := parseChatTargets(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing TELEGRAM_CHAT_IDS: %w", err)
+		}
+		config.Telegram.Chats = chats
+	}
+
+	config.Store.Path = env.GetEnv("STORE_PATH", config.Store.Path)
+
+	config.Reload.WatchInterval = env.GetEnvDuration("CONFIG_WATCH_INTERVAL", config.Reload.WatchInterval)
+
+	config.Log.Level = env.GetEnv("LOG_LEVEL", config.Log.Level)
+	config.Log.Format = env.GetEnv("LOG_FORMAT", config.Log.Format)
+
+	return nil
+}
+
+func (config *Config) applyAccountDefaults() {
+	for i, account := range config.API.WB {
+		if account.MaxNewQuestions == 0 {
+			account.MaxNewQuestions = config.API.MaxNewQuestions
+		}
+		if account.MaxNewFeedbacks == 0 {
+			account.MaxNewFeedbacks = config.API.MaxNewFeedbacks
+		}
+
+		config.API.WB[i] = account.WithDefaults()
+	}
+
+	for i, account := range config.API.Yandex {
+		config.API.Yandex[i] = account.WithDefaults()
+	}
+}
+
+// applyControlToken keeps the single CONTROL_TOKEN working as an operator
+// token named "control".
+func (config *Config) applyControlToken() {
 	if config.Server.ControlToken == "" {
-		return fmt.Errorf("missing control token")
+		return
+	}
+
+	hash := sha256.Sum256([]byte(config.Server.ControlToken))
+
+	config.Server.Auth.Tokens = append(config.Server.Auth.Tokens, APIToken{
+		Name:   "control",
+		Role:   RoleOperator,
+		SHA256: hex.EncodeToString(hash[:]),
+	})
+}
+
+func (config *Config) validate() error {
+	if err := config.Server.Auth.validate(); err != nil {
+		return err
+	}
+
+	if config.Server.ReadinessMaxMissedChecks <= 0 {
+		return fmt.Errorf("readiness max missed checks must be positive")
+	}
+	if config.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive")
+	}
+	if _, err := ip.ParseAllowlist(config.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("error parsing TRUSTED_PROXIES: %w", err)
+	}
+	if _, err := ip.ParseAllowlist(config.Server.YandexAllowedIPs); err != nil {
+		return fmt.Errorf("error parsing YANDEX_ALLOWED_IPS: %w", err)
+	}
+	if config.Monitor.Yandex.WebhookEnabled() && len(config.Server.YandexAllowedIPs) == 0 {
+		return fmt.Errorf("missing YANDEX_ALLOWED_IPS, the Yandex webhook would reject every request")
+	}
+
+	if err := config.Monitor.validate(); err != nil {
+		return err
+	}
+
+	if config.Monitor.WB.Enabled && len(config.API.WB) == 0 {
+		return fmt.Errorf("missing WB_ACCOUNTS or WB_JWT, set WB_ENABLED=false to run without WB")
+	}
+	if config.Monitor.Yandex.Enabled && len(config.API.Yandex) == 0 {
+		return fmt.Errorf("missing YANDEX_ACCOUNTS or YANDEX_TOKEN, set YANDEX_ENABLED=false to run without Yandex")
 	}
 
-	if config.API.WB.JWT == "" {
-		return fmt.Errorf("missing WB_JWT")
+	var wbNames []string
+	for _, account := range config.API.WB {
+		if account.Name == "" || account.JWT == "" {
+			return fmt.Errorf("WB account %q is missing a name or JWT", account.Name)
+		}
+		wbNames = append(wbNames, account.Name)
 	}
-	if config.API.Yandex.APIToken == "" {
-		return fmt.Errorf("missing YANDEX_TOKEN")
+	if err := validateAccountNames("WB", wbNames); err != nil {
+		return err
+	}
+
+	var yandexNames []string
+	for _, account := range config.API.Yandex {
+		if account.Name == "" || account.APIToken == "" {
+			return fmt.Errorf("Yandex account %q is missing a name or API token", account.Name)
+		}
+		if config.Monitor.Yandex.PollingEnabled() && account.BusinessId == 0 {
+			return fmt.Errorf("Yandex account %q needs a business id to be polled", account.Name)
+		}
+		yandexNames = append(yandexNames, account.Name)
+	}
+	if err := validateAccountNames("Yandex", yandexNames); err != nil {
+		return err
 	}
 
 	if config.Telegram.BotToken == "" {
 		return fmt.Errorf("missing TELEGRAM_BOT_TOKEN")
 	}
-	if len(config.Telegram.ChatIds) == 0 {
-		return fmt.Errorf("missing TELEGRAM_CHAT_IDS")
+	if len(config.Telegram.Chats) == 0 && len(config.Telegram.AdminIds) == 0 {
+		return fmt.Errorf("missing TELEGRAM_CHAT_IDS or TELEGRAM_ADMIN_IDS")
+	}
+	if err := validateChatTargets(config.Telegram.Chats); err != nil {
+		return err
+	}
+	if err := config.Telegram.NegativeReviews.validate(); err != nil {
+		return err
+	}
+	if err := config.Telegram.Tagging.validate(); err != nil {
+		return err
+	}
+	if err := validateReplyTemplates(config.Telegram.ReplyTemplates); err != nil {
+		return err
+	}
+
+	if config.Store.Path == "" {
+		return fmt.Errorf("missing STORE_PATH")
+	}
+
+	if _, err := logging.ParseLevel(config.Log.Level); err != nil {
+		return err
+	}
+	if config.Log.Format != "text" && config.Log.Format != "json" {
+		return fmt.Errorf("unknown log format %q, expected text or json", config.Log.Format)
 	}
 
 	return nil
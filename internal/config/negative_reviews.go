@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/utils/env"
+	"strings"
+)
+
+// NegativeReviewConfig picks the feedbacks alerted with priority: ones with
+// MaxStars stars or fewer and, if NotRecommended is set, Yandex ones the buyer
+// doesn't recommend. Their notifications skip ahead of the send queue; the
+// first one also goes to EscalationChats, mentions Mentions and is pinned if
+// Pin is set.
+type NegativeReviewConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	MaxStars        int      `yaml:"maxStars"`
+	NotRecommended  bool     `yaml:"notRecommended"`
+	EscalationChats []string `yaml:"escalationChats"`
+	Mentions        []string `yaml:"mentions"`
+	Pin             bool     `yaml:"pin"`
+}
+
+// Matches reports whether a feedback with the stars is negative, recommended
+// is only known for Yandex.
+func (config NegativeReviewConfig) Matches(stars int, recommended bool) bool {
+	if !config.Enabled {
+		return false
+	}
+
+	return (stars > 0 && stars <= config.MaxStars) || (config.NotRecommended && !recommended)
+}
+
+func (config *NegativeReviewConfig) loadEnv() {
+	config.Enabled = env.GetEnvBool("NEGATIVE_REVIEWS_ENABLED", config.Enabled)
+	config.MaxStars = env.GetEnvInt("NEGATIVE_REVIEW_MAX_STARS", config.MaxStars)
+	config.NotRecommended = env.GetEnvBool("NEGATIVE_REVIEW_NOT_RECOMMENDED", config.NotRecommended)
+	config.EscalationChats = env.GetEnvStringSlice("NEGATIVE_REVIEW_ESCALATION_CHATS", config.EscalationChats)
+	config.Mentions = env.GetEnvStringSlice("NEGATIVE_REVIEW_MENTIONS", config.Mentions)
+	config.Pin = env.GetEnvBool("NEGATIVE_REVIEW_PIN", config.Pin)
+}
+
+func (config *NegativeReviewConfig) validate() error {
+	if config.MaxStars < 0 || config.MaxStars > 5 {
+		return fmt.Errorf("negative review max stars must be between 0 and 5")
+	}
+
+	for _, chatId := range config.EscalationChats {
+		if strings.TrimSpace(chatId) == "" {
+			return fmt.Errorf("empty negative review escalation chat id")
+		}
+	}
+
+	for _, mention := range config.Mentions {
+		if !strings.HasPrefix(mention, "@") || len(mention) < 2 {
+			return fmt.Errorf("negative review mention %q must be an @username", mention)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+func TestNegativeReviewConfigMatches(t *testing.T) {
+	enabled := NegativeReviewConfig{Enabled: true, MaxStars: 2, NotRecommended: true}
+
+	tests := []struct {
+		name        string
+		config      NegativeReviewConfig
+		stars       int
+		recommended bool
+		want        bool
+	}{
+		{name: "disabled", config: NegativeReviewConfig{MaxStars: 2, NotRecommended: true}, stars: 1, recommended: false, want: false},
+		{name: "one star", config: enabled, stars: 1, recommended: true, want: true},
+		{name: "at max stars", config: enabled, stars: 2, recommended: true, want: true},
+		{name: "above max stars", config: enabled, stars: 3, recommended: true, want: false},
+		{name: "no stars", config: enabled, stars: 0, recommended: true, want: false},
+		{name: "not recommended", config: enabled, stars: 5, recommended: false, want: true},
+		{
+			name:        "not recommended ignored",
+			config:      NegativeReviewConfig{Enabled: true, MaxStars: 2},
+			stars:       5,
+			recommended: false,
+			want:        false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.config.Matches(test.stars, test.recommended); got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/utils/env"
+	"strings"
+	"time"
+)
+
+// ReportConfig schedules the rating reports sent to ChatId, or to ThreadId of
+// a forum chat. Both go out at Time in the server's time zone, the weekly one
+// on Weekday.
+type ReportConfig struct {
+	ChatId   string `yaml:"chatId"`
+	ThreadId int    `yaml:"threadId"`
+	Daily    bool   `yaml:"daily"`
+	Weekly   bool   `yaml:"weekly"`
+	Time     string `yaml:"time"`
+	Weekday  string `yaml:"weekday"`
+}
+
+const reportTimeLayout = "15:04"
+
+var weekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sunday":    time.Sunday,
+}
+
+func (config ReportConfig) Enabled() bool {
+	return config.Daily || config.Weekly
+}
+
+// DueAt is when the reports are due on the day of t.
+func (config ReportConfig) DueAt(t time.Time) time.Time {
+	at, _ := time.Parse(reportTimeLayout, config.Time)
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, at.Hour(), at.Minute(), 0, 0, t.Location())
+}
+
+func (config ReportConfig) WeeklyOn() time.Weekday {
+	return weekdays[strings.ToLower(config.Weekday)]
+}
+
+func (config *ReportConfig) loadEnv() {
+	config.ChatId = env.GetEnv("REPORT_CHAT_ID", config.ChatId)
+	config.ThreadId = env.GetEnvInt("REPORT_THREAD_ID", config.ThreadId)
+	config.Daily = env.GetEnvBool("REPORT_DAILY", config.Daily)
+	config.Weekly = env.GetEnvBool("REPORT_WEEKLY", config.Weekly)
+	config.Time = env.GetEnv("REPORT_TIME", config.Time)
+	config.Weekday = env.GetEnv("REPORT_WEEKDAY", config.Weekday)
+}
+
+func (config *ReportConfig) validate() error {
+	if config.Enabled() && config.ChatId == "" {
+		return fmt.Errorf("missing REPORT_CHAT_ID for the rating reports")
+	}
+	if config.ThreadId < 0 {
+		return fmt.Errorf("report thread id must not be negative")
+	}
+	if _, err := time.Parse(reportTimeLayout, config.Time); err != nil {
+		return fmt.Errorf("report time %q must be HH:MM", config.Time)
+	}
+	if _, ok := weekdays[strings.ToLower(config.Weekday)]; !ok {
+		return fmt.Errorf("unknown report weekday %q", config.Weekday)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/utils/env"
+	"strconv"
+	"time"
+)
+
+type SourceConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+type WBScheduleConfig struct {
+	Enabled   bool         `yaml:"enabled"`
+	Questions SourceConfig `yaml:"questions"`
+	Feedbacks SourceConfig `yaml:"feedbacks"`
+}
+
+// YandexScheduleConfig picks how Yandex feedbacks arrive: pushed to the
+// webhook, or in polling mode listed on the feedbacks interval for setups that
+// Yandex can't reach.
+type YandexScheduleConfig struct {
+	Enabled   bool                  `yaml:"enabled"`
+	Mode      string                `yaml:"mode"`
+	Feedbacks SourceConfig          `yaml:"feedbacks"`
+	Webhook   YandexWebhookConfig   `yaml:"webhook"`
+	Reconcile YandexReconcileConfig `yaml:"reconcile"`
+}
+
+// YandexWebhookConfig controls how queued webhook notifications are processed.
+// Notifications received while the monitor is stopped are held until it
+// starts again; then more than SummaryThreshold of them per account are sent
+// as one summary and ones older than MaxBufferAge are dropped. Zero disables
+// either.
+type YandexWebhookConfig struct {
+	Workers          int           `yaml:"workers"`
+	MaxAttempts      int           `yaml:"maxAttempts"`
+	RetryBackoff     time.Duration `yaml:"retryBackoff"`
+	SummaryThreshold int           `yaml:"summaryThreshold"`
+	MaxBufferAge     time.Duration `yaml:"maxBufferAge"`
+}
+
+// YandexReconcileConfig controls the periodic listing of recent feedbacks that
+// catches the ones whose webhook never arrived. Lookback can't exceed how long
+// processed feedbacks are remembered.
+type YandexReconcileConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	Lookback time.Duration `yaml:"lookback"`
+}
+
+const maxReconcileLookback = 7 * 24 * time.Hour
+
+const maxYandexWebhookAttempts = 20
+
+const (
+	YandexWebhookMode = "webhook"
+	YandexPollingMode = "polling"
+)
+
+// IntervalOr returns the source's own interval or the fallback when it has none.
+func (source SourceConfig) IntervalOr(fallback time.Duration) time.Duration {
+	if source.Interval > 0 {
+		return source.Interval
+	}
+
+	return fallback
+}
+
+func (schedule WBScheduleConfig) QuestionsEnabled() bool {
+	return schedule.Enabled && schedule.Questions.Enabled
+}
+
+func (schedule WBScheduleConfig) FeedbacksEnabled() bool {
+	return schedule.Enabled && schedule.Feedbacks.Enabled
+}
+
+func (schedule YandexScheduleConfig) FeedbacksEnabled() bool {
+	return schedule.Enabled && schedule.Feedbacks.Enabled
+}
+
+func (schedule YandexScheduleConfig) WebhookEnabled() bool {
+	return schedule.FeedbacksEnabled() && schedule.Mode == YandexWebhookMode
+}
+
+func (schedule YandexScheduleConfig) PollingEnabled() bool {
+	return schedule.FeedbacksEnabled() && schedule.Mode == YandexPollingMode
+}
+
+// ReconcileEnabled is only relevant to the webhook, polling catches up anyway.
+func (schedule YandexScheduleConfig) ReconcileEnabled() bool {
+	return schedule.WebhookEnabled() && schedule.Reconcile.Enabled
+}
+
+func defaultSourceConfig() SourceConfig {
+	return SourceConfig{Enabled: true}
+}
+
+func (source *SourceConfig) loadEnv(prefix string) {
+	source.Enabled = env.GetEnvBool(prefix+"_ENABLED", source.Enabled)
+	source.Interval = env.GetEnvDuration(prefix+"_INTERVAL", source.Interval)
+}
+
+func (config *MonitorConfig) loadEnv() error {
+	config.Autostart = env.GetEnvBool("AUTOSTART", config.Autostart)
+	config.CheckInterval = env.GetEnvDuration("CHECK_INTERVAL", config.CheckInterval)
+
+	config.WB.Enabled = env.GetEnvBool("WB_ENABLED", config.WB.Enabled)
+	config.WB.Questions.loadEnv("WB_QUESTIONS")
+	config.WB.Feedbacks.loadEnv("WB_FEEDBACKS")
+
+	config.Yandex.Enabled = env.GetEnvBool("YANDEX_ENABLED", config.Yandex.Enabled)
+	config.Yandex.Mode = env.GetEnv("YANDEX_MODE", config.Yandex.Mode)
+	config.Yandex.Feedbacks.loadEnv("YANDEX_FEEDBACKS")
+	config.Yandex.Webhook.Workers = env.GetEnvInt("YANDEX_WEBHOOK_WORKERS", config.Yandex.Webhook.Workers)
+	config.Yandex.Webhook.MaxAttempts = env.GetEnvInt("YANDEX_WEBHOOK_MAX_ATTEMPTS", config.Yandex.Webhook.MaxAttempts)
+	config.Yandex.Webhook.RetryBackoff = env.GetEnvDuration("YANDEX_WEBHOOK_RETRY_BACKOFF", config.Yandex.Webhook.RetryBackoff)
+	config.Yandex.Webhook.SummaryThreshold = env.GetEnvInt("YANDEX_BUFFER_SUMMARY_THRESHOLD", config.Yandex.Webhook.SummaryThreshold)
+	config.Yandex.Webhook.MaxBufferAge = env.GetEnvDuration("YANDEX_BUFFER_MAX_AGE", config.Yandex.Webhook.MaxBufferAge)
+	config.Yandex.Reconcile.Enabled = env.GetEnvBool("YANDEX_RECONCILE_ENABLED", config.Yandex.Reconcile.Enabled)
+	config.Yandex.Reconcile.Interval = env.GetEnvDuration("YANDEX_RECONCILE_INTERVAL", config.Yandex.Reconcile.Interval)
+	config.Yandex.Reconcile.Lookback = env.GetEnvDuration("YANDEX_RECONCILE_LOOKBACK", config.Yandex.Reconcile.Lookback)
+
+	config.TokenCheck.Interval = env.GetEnvDuration("WB_TOKEN_CHECK_INTERVAL", config.TokenCheck.Interval)
+	if entries := env.GetEnvStringSlice("WB_TOKEN_ALERT_DAYS", nil); entries != nil {
+		alertDays, err := parseAlertDays(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing WB_TOKEN_ALERT_DAYS: %w", err)
+		}
+		config.TokenCheck.AlertDays = alertDays
+	}
+
+	config.Report.loadEnv()
+
+	return nil
+}
+
+func parseAlertDays(entries []string) ([]int, error) {
+	alertDays := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		days, err := strconv.Atoi(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of days %q", entry)
+		}
+		alertDays = append(alertDays, days)
+	}
+
+	return alertDays, nil
+}
+
+func (config *MonitorConfig) validate() error {
+	if config.CheckInterval <= 0 {
+		return fmt.Errorf("check interval must be positive")
+	}
+
+	if config.TokenCheck.Interval <= 0 {
+		return fmt.Errorf("token check interval must be positive")
+	}
+	for _, days := range config.TokenCheck.AlertDays {
+		if days <= 0 {
+			return fmt.Errorf("token alert days must be positive")
+		}
+	}
+
+	if err := config.Report.validate(); err != nil {
+		return err
+	}
+
+	for name, source := range map[string]SourceConfig{
+		"WB questions":     config.WB.Questions,
+		"WB feedbacks":     config.WB.Feedbacks,
+		"Yandex feedbacks": config.Yandex.Feedbacks,
+	} {
+		if source.Interval < 0 {
+			return fmt.Errorf("%s interval must not be negative", name)
+		}
+	}
+
+	if config.Yandex.Mode != YandexWebhookMode && config.Yandex.Mode != YandexPollingMode {
+		return fmt.Errorf("unknown Yandex mode %q, expected %s or %s", config.Yandex.Mode, YandexWebhookMode, YandexPollingMode)
+	}
+
+	if config.Yandex.Webhook.Workers <= 0 {
+		return fmt.Errorf("Yandex webhook workers must be positive")
+	}
+	if config.Yandex.Webhook.MaxAttempts <= 0 || config.Yandex.Webhook.MaxAttempts > maxYandexWebhookAttempts {
+		return fmt.Errorf("Yandex webhook max attempts must be positive and at most %d", maxYandexWebhookAttempts)
+	}
+	if config.Yandex.Webhook.RetryBackoff <= 0 {
+		return fmt.Errorf("Yandex webhook retry backoff must be positive")
+	}
+	if config.Yandex.Webhook.SummaryThreshold < 0 || config.Yandex.Webhook.MaxBufferAge < 0 {
+		return fmt.Errorf("Yandex buffer summary threshold and max age must not be negative")
+	}
+	if config.Yandex.Reconcile.Interval <= 0 {
+		return fmt.Errorf("Yandex reconcile interval must be positive")
+	}
+	if config.Yandex.Reconcile.Lookback <= 0 || config.Yandex.Reconcile.Lookback > maxReconcileLookback {
+		return fmt.Errorf("Yandex reconcile lookback must be positive and at most %s", maxReconcileLookback)
+	}
+
+	return nil
+}
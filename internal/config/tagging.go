@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/tagging"
+	"marketplace-notifications/internal/utils/env"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TaggingConfig tags incoming texts by Rules. Reactions with any of a route's
+// tags are also sent to its chat.
+type TaggingConfig struct {
+	Rules  []tagging.Rule `yaml:"rules"`
+	Routes []TagRoute     `yaml:"routes"`
+}
+
+type TagRoute struct {
+	Tags     []string `yaml:"tags"`
+	ChatId   string   `yaml:"chatId"`
+	ThreadId int      `yaml:"threadId"`
+}
+
+// Matches reports whether the route takes a reaction with the tags.
+func (route TagRoute) Matches(tags []string) bool {
+	for _, tag := range route.Tags {
+		if slices.Contains(tags, strings.ToLower(tag)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (config *TaggingConfig) loadEnv() error {
+	if entries := env.GetEnvStringSlice("TAG_RULES", nil); entries != nil {
+		rules, err := tagging.ParseRules(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing TAG_RULES: %w", err)
+		}
+		config.Rules = rules
+	}
+
+	if entries := env.GetEnvStringSlice("TAG_ROUTES", nil); entries != nil {
+		routes, err := parseTagRoutes(entries)
+		if err != nil {
+			return fmt.Errorf("error parsing TAG_ROUTES: %w", err)
+		}
+		config.Routes = routes
+	}
+
+	return nil
+}
+
+// parseTagRoutes parses entries of the form "chatId|tag|tag|...", with an
+// optional "thread=threadId" part for forum chats.
+func parseTagRoutes(entries []string) ([]TagRoute, error) {
+	routes := make([]TagRoute, 0, len(entries))
+
+	for _, entry := range entries {
+		parts := strings.Split(entry, "|")
+
+		route := TagRoute{ChatId: strings.TrimSpace(parts[0])}
+
+		for _, part := range parts[1:] {
+			part = strings.TrimSpace(part)
+
+			if rawThreadId, found := strings.CutPrefix(part, "thread="); found {
+				threadId, err := strconv.Atoi(rawThreadId)
+				if err != nil {
+					return nil, fmt.Errorf("invalid thread id %q for chat %s", rawThreadId, route.ChatId)
+				}
+				route.ThreadId = threadId
+				continue
+			}
+
+			route.Tags = append(route.Tags, part)
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+func (config *TaggingConfig) validate() error {
+	if _, err := tagging.New(config.Rules); err != nil {
+		return err
+	}
+
+	var tags []string
+	for _, rule := range config.Rules {
+		tags = append(tags, strings.ToLower(rule.Tag))
+	}
+
+	for _, route := range config.Routes {
+		if route.ChatId == "" {
+			return fmt.Errorf("tag route without a chat id")
+		}
+		if len(route.Tags) == 0 {
+			return fmt.Errorf("tag route to chat %s has no tags", route.ChatId)
+		}
+		if route.ThreadId < 0 {
+			return fmt.Errorf("tag route to chat %s has a negative thread id", route.ChatId)
+		}
+
+		for _, tag := range route.Tags {
+			if !slices.Contains(tags, strings.ToLower(tag)) {
+				return fmt.Errorf("tag route to chat %s uses unknown tag %q", route.ChatId, tag)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/templates"
+)
+
+func validateReplyTemplates(list []templates.Template) error {
+	ids := make(map[string]bool, len(list))
+	for _, template := range list {
+		if err := template.Validate(); err != nil {
+			return err
+		}
+
+		if ids[template.Id] {
+			return fmt.Errorf("duplicate reply template id %q", template.Id)
+		}
+		ids[template.Id] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"marketplace-notifications/internal/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	XLSX   Format = "xlsx"
+	NDJSON Format = "ndjson"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case CSV, XLSX, NDJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, expected csv, xlsx or ndjson", value)
+	}
+}
+
+func (format Format) ContentType() string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// FileName names an export made at the given time.
+func (format Format) FileName(at time.Time) string {
+	return fmt.Sprintf("reactions-%s.%s", at.Format("20060102-150405"), format)
+}
+
+type column struct {
+	header string
+	value  func(reaction store.Reaction) cell
+}
+
+// cell is a spreadsheet value, numeric ones stay numbers in XLSX.
+type cell struct {
+	text      string
+	isNumeric bool
+}
+
+func text(value string) cell {
+	return cell{text: value}
+}
+
+func number(value int) cell {
+	if value == 0 {
+		return cell{}
+	}
+
+	return cell{text: strconv.Itoa(value), isNumeric: true}
+}
+
+func timestamp(value time.Time) cell {
+	if value.IsZero() {
+		return cell{}
+	}
+
+	return cell{text: value.Format(time.RFC3339)}
+}
+
+var columns = []column{
+	{"id", func(reaction store.Reaction) cell { return text(reaction.Id) }},
+	{"marketplace", func(reaction store.Reaction) cell { return text(reaction.Marketplace) }},
+	{"account", func(reaction store.Reaction) cell { return text(reaction.Account) }},
+	{"type", func(reaction store.Reaction) cell { return text(reaction.Type) }},
+	{"itemId", func(reaction store.Reaction) cell { return text(reaction.ItemId) }},
+	{"rating", func(reaction store.Reaction) cell { return number(reaction.Rating) }},
+	{"priority", func(reaction store.Reaction) cell { return text(strconv.FormatBool(reaction.Priority)) }},
+	{"article", func(reaction store.Reaction) cell { return text(reaction.Article) }},
+	{"productName", func(reaction store.Reaction) cell { return text(reaction.ProductName) }},
+	{"orderId", func(reaction store.Reaction) cell { return text(reaction.OrderId) }},
+	{"customer", func(reaction store.Reaction) cell { return text(reaction.Customer) }},
+	{"tags", func(reaction store.Reaction) cell { return text(strings.Join(reaction.Tags, ",")) }},
+	{"text", func(reaction store.Reaction) cell { return text(reaction.Text) }},
+	{"pros", func(reaction store.Reaction) cell { return text(reaction.Pros) }},
+	{"cons", func(reaction store.Reaction) cell { return text(reaction.Cons) }},
+	{"answered", func(reaction store.Reaction) cell { return text(strconv.FormatBool(reaction.Answered)) }},
+	{"answer", func(reaction store.Reaction) cell { return text(reaction.Answer) }},
+	{"createdAt", func(reaction store.Reaction) cell { return timestamp(reaction.CreatedAt) }},
+	{"receivedAt", func(reaction store.Reaction) cell { return timestamp(reaction.ReceivedAt) }},
+	{"answeredAt", func(reaction store.Reaction) cell { return timestamp(reaction.AnsweredAt) }},
+}
+
+// Write exports the reactions in the format.
+func Write(w io.Writer, format Format, reactions []store.Reaction) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, reactions)
+	case XLSX:
+		return writeXLSX(w, reactions)
+	case NDJSON:
+		return writeNDJSON(w, reactions)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func writeCSV(w io.Writer, reactions []store.Reaction) error {
+	// The byte order mark makes Excel read the file as UTF-8.
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, reaction := range reactions {
+		record := make([]string, 0, len(columns))
+		for _, column := range columns {
+			record = append(record, escapeFormula(column.value(reaction).text))
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula keeps spreadsheets from evaluating customer-written text that
+// starts like a formula.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func writeNDJSON(w io.Writer, reactions []store.Reaction) error {
+	encoder := json.NewEncoder(w)
+	for _, reaction := range reactions {
+		if err := encoder.Encode(reaction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"marketplace-notifications/internal/store"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testReactions = []store.Reaction{
+	{
+		Id:          "wb:feedbacks:1",
+		Marketplace: "WB",
+		Type:        "feedbacks",
+		ItemId:      "1",
+		Rating:      2,
+		Priority:    true,
+		Customer:    "Анна",
+		Tags:        []string{"брак", "размер"},
+		Text:        "=HYPERLINK(\"http://example.com\") <брак> & \x01",
+		CreatedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	},
+	{
+		Id:          "yandex:feedbacks:2",
+		Marketplace: "Yandex",
+		Type:        "feedbacks",
+		ItemId:      "2",
+		Answered:    true,
+		Answer:      "Спасибо!",
+	},
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Format
+		wantErr bool
+	}{
+		{value: "csv", want: CSV},
+		{value: "XLSX", want: XLSX},
+		{value: "ndjson", want: NDJSON},
+		{value: "json", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		format, err := ParseFormat(test.value)
+		if (err != nil) != test.wantErr || format != test.want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q, error %v", test.value, format, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Хороший товар", "Хороший товар"},
+		{"=1+1", "'=1+1"},
+		{"+7 999", "'+7 999"},
+		{"-5", "'-5"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"a=b", "a=b"},
+	}
+
+	for _, test := range tests {
+		if got := escapeFormula(test.value); got != test.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, test := range tests {
+		if got := columnName(test.index); got != test.want {
+			t.Errorf("columnName(%d) = %q, want %q", test.index, got, test.want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, CSV, testReactions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, found := strings.CutPrefix(buffer.String(), "\ufeff")
+	if !found {
+		t.Fatal("missing byte order mark")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	if len(records) != len(testReactions)+1 {
+		t.Fatalf("got %d records, want %d", len(records), len(testReactions)+1)
+	}
+
+	wantHeaders := []string{
+		"id", "marketplace", "account", "type", "itemId", "rating", "priority", "article", "productName", "orderId",
+		"customer", "tags", "text", "pros", "cons", "answered", "answer", "createdAt", "receivedAt", "answeredAt",
+	}
+	if !slices.Equal(records[0], wantHeaders) {
+		t.Fatalf("got headers %v, want %v", records[0], wantHeaders)
+	}
+
+	row := csvRow(records[0], records[1])
+	for header, want := range map[string]string{
+		"id":        "wb:feedbacks:1",
+		"rating":    "2",
+		"priority":  "true",
+		"customer":  "Анна",
+		"tags":      "брак,размер",
+		"text":      "'" + testReactions[0].Text,
+		"answered":  "false",
+		"createdAt": "2026-01-02T03:04:05Z",
+	} {
+		if row[header] != want {
+			t.Errorf("%s = %q, want %q", header, row[header], want)
+		}
+	}
+
+	row = csvRow(records[0], records[2])
+	if row["rating"] != "" || row["createdAt"] != "" || row["answer"] != "Спасибо!" {
+		t.Errorf("got %v, want empty rating and time and the answer", row)
+	}
+}
+
+func csvRow(headers, record []string) map[string]string {
+	row := make(map[string]string, len(headers))
+	for i, header := range headers {
+		row[header] = record[i]
+	}
+
+	return row
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, XLSX, testReactions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+
+	parts := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if parts[name] == nil {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+
+	file, err := parts["xl/worksheets/sheet1.xml"].Open()
+	if err != nil {
+		t.Fatalf("failed to open sheet: %v", err)
+	}
+	defer file.Close()
+
+	var sheet xlsxSheet
+	if err := xml.NewDecoder(file).Decode(&sheet); err != nil {
+		t.Fatalf("sheet is not valid XML: %v", err)
+	}
+
+	if len(sheet.Rows) != len(testReactions)+1 {
+		t.Fatalf("got %d rows, want %d", len(sheet.Rows), len(testReactions)+1)
+	}
+
+	cells := make(map[string]string)
+	types := make(map[string]string)
+	for _, row := range sheet.Rows {
+		for _, cell := range row.Cells {
+			cells[cell.Ref] = cell.Value + cell.Inline
+			types[cell.Ref] = cell.Type
+		}
+	}
+
+	tests := []struct {
+		ref      string
+		want     string
+		wantType string
+	}{
+		{ref: "A1", want: "id", wantType: "inlineStr"},
+		{ref: "A2", want: "wb:feedbacks:1", wantType: "inlineStr"},
+		{ref: "F2", want: "2", wantType: ""},
+		{ref: "G1", want: "priority", wantType: "inlineStr"},
+		{ref: "K1", want: "customer", wantType: "inlineStr"},
+		{ref: "K2", want: "Анна", wantType: "inlineStr"},
+		{ref: "M2", want: "=HYPERLINK(\"http://example.com\") <брак> & ", wantType: "inlineStr"},
+		{ref: "F3", want: "", wantType: ""},
+		{ref: "Q3", want: "Спасибо!", wantType: "inlineStr"},
+	}
+
+	for _, test := range tests {
+		if cells[test.ref] != test.want || types[test.ref] != test.wantType {
+			t.Errorf("cell %s = %q of type %q, want %q of type %q", test.ref, cells[test.ref], types[test.ref], test.want, test.wantType)
+		}
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Write(&buffer, NDJSON, testReactions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buffer)
+	var ids []string
+	for scanner.Scan() {
+		var reaction store.Reaction
+		if err := json.Unmarshal(scanner.Bytes(), &reaction); err != nil {
+			t.Fatalf("line %d is not JSON: %v", len(ids)+1, err)
+		}
+		ids = append(ids, reaction.Id)
+	}
+
+	if strings.Join(ids, " ") != "wb:feedbacks:1 yandex:feedbacks:2" {
+		t.Fatalf("got ids %v", ids)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(io.Discard, Format("pdf"), testReactions); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"marketplace-notifications/internal/store"
+	"strings"
+)
+
+// The smallest package Excel and LibreOffice open: one sheet with inline
+// strings, so no shared string table or styles are needed.
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Reactions" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+func writeXLSX(w io.Writer, reactions []store.Reaction) error {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("failed to add sheet: %w", err)
+	}
+	if err := writeSheet(sheet, reactions); err != nil {
+		return fmt.Errorf("failed to write sheet: %w", err)
+	}
+
+	return archive.Close()
+}
+
+func writeSheet(w io.Writer, reactions []store.Reaction) error {
+	var sheet bytes.Buffer
+
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	headers := make([]cell, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, text(column.header))
+	}
+	writeRow(&sheet, 1, headers)
+
+	for i, reaction := range reactions {
+		cells := make([]cell, 0, len(columns))
+		for _, column := range columns {
+			cells = append(cells, column.value(reaction))
+		}
+		writeRow(&sheet, i+2, cells)
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	_, err := sheet.WriteTo(w)
+	return err
+}
+
+func writeRow(sheet *bytes.Buffer, row int, cells []cell) {
+	fmt.Fprintf(sheet, `<row r="%d">`, row)
+
+	for i, cell := range cells {
+		if cell.text == "" {
+			continue
+		}
+
+		ref := columnName(i) + fmt.Sprint(row)
+		if cell.isNumeric {
+			fmt.Fprintf(sheet, `<c r="%s"><v>%s</v></c>`, ref, cell.text)
+			continue
+		}
+
+		fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		xml.EscapeText(sheet, []byte(stripControlChars(cell.text)))
+		sheet.WriteString(`</t></is></c>`)
+	}
+
+	sheet.WriteString(`</row>`)
+}
+
+// columnName turns a zero-based index into a column letter: A, B, ..., AA.
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
+
+// stripControlChars drops characters XML 1.0 can't contain.
+func stripControlChars(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, value)
+}
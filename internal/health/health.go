@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type Status string
+
+const (
+	StatusOk   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+type Component struct {
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+type Checker func(ctx context.Context) Component
+
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+func Ok(message string, details any) Component {
+	return Component{Status: StatusOk, Message: message, Details: details}
+}
+
+func Fail(message string, details any) Component {
+	return Component{Status: StatusFail, Message: message, Details: details}
+}
+
+// Check runs all checkers concurrently. The report fails if any component does.
+func Check(ctx context.Context, checkers map[string]Checker) Report {
+	report := Report{
+		Status:     StatusOk,
+		Components: make(map[string]Component, len(checkers)),
+	}
+
+	names := make([]string, 0, len(checkers))
+	for name := range checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	components := make([]Component, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			components[i] = checkers[name](ctx)
+		}()
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		report.Components[name] = components[i]
+		if components[i].Status != StatusOk {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
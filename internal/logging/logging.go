@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+var level = new(slog.LevelVar)
+
+// Setup installs the default slog logger. Secrets registered with AddSecrets
+// are redacted from every message and attribute, before and after Setup.
+func Setup(w io.Writer, levelName, format string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, options)
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+
+	return nil
+}
+
+// SetLevel changes the level of the logger installed by Setup.
+func SetLevel(levelName string) error {
+	parsed, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	level.Set(parsed)
+
+	return nil
+}
+
+func ParseLevel(levelName string) (slog.Level, error) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(levelName)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", levelName)
+	}
+
+	return parsed, nil
+}
+
+func NewCorrelationId() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+
+	return hex.EncodeToString(bytes)
+}
+
+type loggerKey struct{}
+
+type correlationIdKey struct{}
+
+// WithCorrelationId tags ctx and its logger with a new correlation ID.
+func WithCorrelationId(ctx context.Context) (context.Context, *slog.Logger) {
+	correlationId := NewCorrelationId()
+	ctx = context.WithValue(ctx, correlationIdKey{}, correlationId)
+
+	return With(ctx, "correlationId", correlationId)
+}
+
+func CorrelationId(ctx context.Context) string {
+	correlationId, _ := ctx.Value(correlationIdKey{}).(string)
+	return correlationId
+}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored by WithLogger, or the default one.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// With stores a logger with the given attributes added in the returned context.
+func With(ctx context.Context, args ...any) (context.Context, *slog.Logger) {
+	logger := FromContext(ctx).With(args...)
+
+	return WithLogger(ctx, logger), logger
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	secretsMutex sync.RWMutex
+	secrets      = make(map[string]struct{})
+
+	// Bot tokens embedded in Telegram API URLs, even ones we don't know about.
+	botTokenPattern = regexp.MustCompile(`bot\d+:[A-Za-z0-9_-]+`)
+	bearerPattern   = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+)
+
+// AddSecrets makes sure the given values never appear in log output.
+func AddSecrets(values ...string) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	for _, value := range values {
+		if len(value) >= 4 {
+			secrets[value] = struct{}{}
+		}
+	}
+}
+
+func Redact(text string) string {
+	secretsMutex.RLock()
+	for secret := range secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	secretsMutex.RUnlock()
+
+	text = botTokenPattern.ReplaceAllString(text, "bot"+redacted)
+	text = bearerPattern.ReplaceAllString(text, "Bearer "+redacted)
+
+	return text
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+
+	return attr
+}
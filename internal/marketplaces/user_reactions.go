@@ -6,3 +6,11 @@ const (
 	Question UserReactionType = iota
 	Feedback
 )
+
+func (reactionType UserReactionType) String() string {
+	if reactionType == Question {
+		return "questions"
+	}
+
+	return "feedbacks"
+}
@@ -0,0 +1,5 @@
+package wb
+
+type Answer struct {
+	Text string `json:"text"`
+}
@@ -5,14 +5,15 @@ import (
 )
 
 type Config struct {
-	JWT             string
-	RPS             int
-	Burst           int
-	BaseURL         string
-	QuestionsPath   string
-	FeedbacksPath   string
-	MaxNewQuestions int
-	MaxNewFeedbacks int
+	Name            string `yaml:"name"`
+	JWT             string `yaml:"jwt"`
+	RPS             int    `yaml:"rps"`
+	Burst           int    `yaml:"burst"`
+	BaseURL         string `yaml:"baseURL"`
+	QuestionsPath   string `yaml:"questionsPath"`
+	FeedbacksPath   string `yaml:"feedbacksPath"`
+	MaxNewQuestions int    `yaml:"maxNewQuestions"`
+	MaxNewFeedbacks int    `yaml:"maxNewFeedbacks"`
 }
 
 func (config Config) QuestionsURL() string {
@@ -33,8 +34,13 @@ func (config Config) FeedbacksURL() string {
 	return url.String()
 }
 
-func GetConfig(JWT string, maxNewQuestions, maxNewFeedbacks int) Config {
+func (config Config) FeedbackAnswerURL() string {
+	return config.FeedbacksURL() + "/answer"
+}
+
+func GetConfig(name, JWT string, maxNewQuestions, maxNewFeedbacks int) Config {
 	return Config{
+		Name:            name,
 		JWT:             JWT,
 		RPS:             3,
 		Burst:           6,
@@ -45,3 +51,26 @@ func GetConfig(JWT string, maxNewQuestions, maxNewFeedbacks int) Config {
 		MaxNewFeedbacks: maxNewFeedbacks,
 	}
 }
+
+// WithDefaults fills in the settings a config file is allowed to omit.
+func (config Config) WithDefaults() Config {
+	defaults := GetConfig(config.Name, config.JWT, config.MaxNewQuestions, config.MaxNewFeedbacks)
+
+	if config.RPS == 0 {
+		config.RPS = defaults.RPS
+	}
+	if config.Burst == 0 {
+		config.Burst = defaults.Burst
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaults.BaseURL
+	}
+	if config.QuestionsPath == "" {
+		config.QuestionsPath = defaults.QuestionsPath
+	}
+	if config.FeedbacksPath == "" {
+		config.FeedbacksPath = defaults.FeedbacksPath
+	}
+
+	return config
+}
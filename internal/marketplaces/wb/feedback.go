@@ -13,8 +13,10 @@ type Feedback struct {
 	Pros           string         `json:"pros"`
 	Cons           string         `json:"cons"`
 	Text           string         `json:"text"`
+	UserName       string         `json:"userName"`
 	ProductDetails ProductDetails `json:"productDetails"`
 	CreatedDate    time.Time      `json:"createdDate"`
+	Answer         *Answer        `json:"answer"`
 }
 
 func (feedback Feedback) FormatMarkdown() string {
@@ -12,6 +12,7 @@ type Question struct {
 	Text           string         `json:"text"`
 	ProductDetails ProductDetails `json:"productDetails"`
 	CreatedDate    time.Time      `json:"createdDate"`
+	Answer         *Answer        `json:"answer"`
 }
 
 func (question Question) FormatMarkdown() string {
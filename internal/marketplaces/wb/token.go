@@ -0,0 +1,102 @@
+package wb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const FeedbacksAndQuestionsScope = "feedbacks_questions"
+
+// WriteScope stands for the write access of tokens that aren't read-only.
+const WriteScope = "write"
+
+// scopeBits maps bits of the token's "s" claim to the API categories it grants.
+var scopeBits = []struct {
+	bit   uint
+	scope string
+}{
+	{1, "content"},
+	{2, "analytics"},
+	{3, "prices"},
+	{4, "marketplace"},
+	{5, "statistics"},
+	{6, "promotion"},
+	{7, FeedbacksAndQuestionsScope},
+	{9, "chats"},
+	{10, "supplies"},
+	{11, "returns"},
+	{12, "documents"},
+}
+
+const readOnlyBit = 30
+
+type TokenInfo struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Scopes    []string  `json:"scopes"`
+	ReadOnly  bool      `json:"readOnly"`
+	Test      bool      `json:"test"`
+}
+
+type tokenClaims struct {
+	ExpiresAt int64  `json:"exp"`
+	Scopes    uint64 `json:"s"`
+	Test      bool   `json:"t"`
+}
+
+// ParseToken decodes the claims of a WB API token without verifying its
+// signature, which only WB can do.
+func ParseToken(JWT string) (TokenInfo, error) {
+	parts := strings.Split(JWT, ".")
+	if len(parts) != 3 {
+		return TokenInfo{}, fmt.Errorf("token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+
+	if claims.ExpiresAt == 0 {
+		return TokenInfo{}, fmt.Errorf("token has no expiry")
+	}
+
+	info := TokenInfo{
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		Scopes:    []string{},
+		ReadOnly:  claims.Scopes&(1<<readOnlyBit) != 0,
+		Test:      claims.Test,
+	}
+
+	for _, scopeBit := range scopeBits {
+		if claims.Scopes&(1<<scopeBit.bit) != 0 {
+			info.Scopes = append(info.Scopes, scopeBit.scope)
+		}
+	}
+
+	return info, nil
+}
+
+// DaysLeft counts the days the token is still valid from now on, a started
+// day included. It is zero or less once the token has expired.
+func (info TokenInfo) DaysLeft(now time.Time) int {
+	return int(math.Ceil(info.ExpiresAt.Sub(now).Hours() / 24))
+}
+
+func (info TokenInfo) HasScope(scope string) bool {
+	for _, tokenScope := range info.Scopes {
+		if tokenScope == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -6,26 +6,51 @@ import (
 )
 
 type Config struct {
-	APIToken string
-	BaseURL  string
-	RPS      int
-	Burst    int
+	Name       string `yaml:"name"`
+	APIToken   string `yaml:"apiToken"`
+	BusinessId int    `yaml:"businessId"`
+	BaseURL    string `yaml:"baseURL"`
+	RPS        int    `yaml:"rps"`
+	Burst      int    `yaml:"burst"`
 }
 
 func (config Config) FeedbacksURL(businessId int) string {
 	var url strings.Builder
 
-	url.WriteString(config.BaseURL)
+	url.WriteString(strings.TrimSuffix(config.BaseURL, "/"))
 	url.WriteString(fmt.Sprintf("/businesses/%d/goods-feedback", businessId))
 
 	return url.String()
 }
 
-func GetConfig(APIToken string) Config {
+func (config Config) FeedbackCommentURL(businessId int) string {
+	return config.FeedbacksURL(businessId) + "/comments/update"
+}
+
+func GetConfig(name, APIToken string, businessId int) Config {
 	return Config{
-		APIToken: APIToken,
-		RPS:      3,
-		Burst:    6,
-		BaseURL:  "https://api.partner.market.yandex.ru/",
+		Name:       name,
+		APIToken:   APIToken,
+		BusinessId: businessId,
+		RPS:        3,
+		Burst:      6,
+		BaseURL:    "https://api.partner.market.yandex.ru",
 	}
 }
+
+// WithDefaults fills in the settings a config file is allowed to omit.
+func (config Config) WithDefaults() Config {
+	defaults := GetConfig(config.Name, config.APIToken, config.BusinessId)
+
+	if config.BaseURL == "" {
+		config.BaseURL = defaults.BaseURL
+	}
+	if config.RPS == 0 {
+		config.RPS = defaults.RPS
+	}
+	if config.Burst == 0 {
+		config.Burst = defaults.Burst
+	}
+
+	return config
+}
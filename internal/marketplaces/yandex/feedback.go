@@ -17,11 +17,13 @@ type Feedback struct {
 		NumberOfStars int  `json:"rating"`
 		Recommended   bool `json:"recommended"`
 	} `json:"statistics"`
+	Author      string `json:"author"`
 	Identifiers struct {
 		OrderId int `json:"orderId"`
 	} `json:"identifiers"`
-	Id          int       `json:"feedbackId"`
-	CreatedDate time.Time `json:"createdAt"`
+	Id           int       `json:"feedbackId"`
+	CreatedDate  time.Time `json:"createdAt"`
+	NeedReaction bool      `json:"needReaction"`
 }
 
 func (feedback Feedback) FormatMarkdown() string {
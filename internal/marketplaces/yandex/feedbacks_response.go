@@ -3,5 +3,8 @@ package yandex
 type FeedbacksResponse struct {
 	Result struct {
 		Feedbacks []Feedback `json:"feedbacks"`
+		Paging    struct {
+			NextPageToken string `json:"nextPageToken"`
+		} `json:"paging"`
 	} `json:"result"`
 }
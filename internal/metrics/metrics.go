@@ -0,0 +1,66 @@
+package metrics
+
+var (
+	MarketplaceRequests = NewCounterVec(
+		"marketplace_api_requests_total",
+		"Marketplace API calls by endpoint and HTTP status.",
+		"marketplace", "endpoint", "status",
+	)
+	MarketplaceRequestDuration = NewHistogramVec(
+		"marketplace_api_request_duration_seconds",
+		"Marketplace API call latency.",
+		DefaultBuckets,
+		"marketplace", "endpoint",
+	)
+	RateLimiterWait = NewHistogramVec(
+		"rate_limiter_wait_seconds",
+		"Time spent waiting for a rate limiter before an API call.",
+		DefaultBuckets,
+		"limiter",
+	)
+	ItemsFound = NewCounterVec(
+		"marketplace_items_found_total",
+		"Unanswered questions and feedbacks found.",
+		"marketplace", "type",
+	)
+	ItemsNotified = NewCounterVec(
+		"marketplace_items_notified_total",
+		"Questions and feedbacks delivered to at least one chat.",
+		"marketplace", "type",
+	)
+	UnansweredItems = NewGaugeVec(
+		"marketplace_unanswered_items",
+		"WB questions and feedbacks in the history that are still unanswered.",
+		"marketplace", "type",
+	)
+	TelegramSends = NewCounterVec(
+		"telegram_messages_sent_total",
+		"Telegram messages sent by chat and outcome.",
+		"chat", "outcome",
+	)
+	YandexWebhooks = NewCounterVec(
+		"yandex_webhook_requests_total",
+		"Yandex webhook requests by notification type and result.",
+		"type", "result",
+	)
+	YandexJobs = NewCounterVec(
+		"yandex_webhook_jobs_total",
+		"Processing attempts of queued Yandex webhook notifications by result.",
+		"result",
+	)
+	YandexReconciled = NewCounterVec(
+		"yandex_reconciled_feedbacks_total",
+		"Yandex feedbacks found through the listing API that no webhook delivered.",
+	)
+	AnswersPosted = NewCounterVec(
+		"marketplace_answers_posted_total",
+		"Answers posted to the marketplaces from Telegram, by result.",
+		"marketplace", "type", "result",
+	)
+	CheckDuration = NewHistogramVec(
+		"monitor_check_duration_seconds",
+		"Duration of a single source check cycle.",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		"marketplace", "type",
+	)
+)
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var DefaultBuckets = prometheus.DefBuckets
+
+// registry holds the metrics of the app together with the Go runtime and
+// process ones.
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves all registered metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)}
+	registry.MustRegister(counter.vec)
+
+	return counter
+}
+
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.vec.WithLabelValues(labelValues...).Inc()
+}
+
+func (counter *CounterVec) Add(delta float64, labelValues ...string) {
+	counter.vec.WithLabelValues(labelValues...).Add(delta)
+}
+
+type GaugeVec struct {
+	vec *prometheus.GaugeVec
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	gauge := &GaugeVec{prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)}
+	registry.MustRegister(gauge.vec)
+
+	return gauge
+}
+
+func (gauge *GaugeVec) Set(newValue float64, labelValues ...string) {
+	gauge.vec.WithLabelValues(labelValues...).Set(newValue)
+}
+
+type HistogramVec struct {
+	vec *prometheus.HistogramVec
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	histogram := &HistogramVec{prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)}
+	registry.MustRegister(histogram.vec)
+
+	return histogram
+}
+
+func (histogramVec *HistogramVec) Observe(observed float64, labelValues ...string) {
+	histogramVec.vec.WithLabelValues(labelValues...).Observe(observed)
+}
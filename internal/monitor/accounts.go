@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"time"
+)
+
+type accountStatus struct {
+	Marketplace          string    `json:"marketplace"`
+	Account              string    `json:"account"`
+	LastCheck            time.Time `json:"lastCheck"`
+	LastUpdateDiscovered time.Time `json:"lastUpdateDiscovered"`
+}
+
+// accountStatus must be called with accountsMutex held.
+func (monitor *Monitor) accountStatus(marketplace, account string) accountStatus {
+	if status, ok := monitor.accounts[marketplace+"/"+account]; ok {
+		return *status
+	}
+
+	return accountStatus{Marketplace: marketplace, Account: account}
+}
+
+func (monitor *Monitor) recordCheck(marketplace, account string) {
+	monitor.updateAccountStatus(marketplace, account, func(status *accountStatus) {
+		status.LastCheck = time.Now()
+		monitor.lastCheck = status.LastCheck
+	})
+}
+
+func (monitor *Monitor) recordUpdateDiscovered(marketplace, account string, discoveredAt time.Time) {
+	monitor.updateAccountStatus(marketplace, account, func(status *accountStatus) {
+		status.LastUpdateDiscovered = discoveredAt
+		monitor.lastUpdateDiscovered = discoveredAt
+	})
+}
+
+func (monitor *Monitor) updateAccountStatus(marketplace, account string, update func(status *accountStatus)) {
+	monitor.accountsMutex.Lock()
+	defer monitor.accountsMutex.Unlock()
+
+	key := marketplace + "/" + account
+
+	status, ok := monitor.accounts[key]
+	if !ok {
+		status = &accountStatus{Marketplace: marketplace, Account: account}
+		monitor.accounts[key] = status
+	}
+
+	update(status)
+}
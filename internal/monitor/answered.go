@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"context"
+	"maps"
+	"marketplace-notifications/internal/client"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"marketplace-notifications/internal/store"
+	"slices"
+	"strconv"
+)
+
+// unansweredWBIds lists the items of the account still unanswered in the
+// history, so that answers given while the service was down are found too.
+func (monitor *Monitor) unansweredWBIds(account string, reactionType marketplaces.UserReactionType) []string {
+	unanswered := false
+
+	var ids []string
+	for _, reaction := range monitor.store.AllReactions(store.ReactionFilter{Marketplace: "WB", Type: reactionType.String(), Answered: &unanswered}) {
+		if reaction.Account == account {
+			ids = append(ids, reaction.ItemId)
+		}
+	}
+
+	return ids
+}
+
+func (monitor *Monitor) checkForAnsweredQuestions(ctx context.Context, wbClient *client.WBClient, unansweredQuestions []wb.Question) {
+	account := wbClient.Name()
+
+	pendingIds := monitor.unansweredWBIds(account, marketplaces.Question)
+	if len(pendingIds) == 0 {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	logger.Info("Checking for answered questions")
+
+	answeredQuestions, err := wbClient.FetchAnsweredQuestions()
+	if err != nil {
+		logger.Error("Failed to check for answered questions", "error", err)
+		return
+	}
+
+	unansweredIds := make(map[string]bool, len(unansweredQuestions))
+	for _, question := range unansweredQuestions {
+		unansweredIds[question.Id] = true
+	}
+
+	answers := make(map[string]string, len(answeredQuestions))
+	for _, question := range answeredQuestions {
+		answers[question.Id] = answerText(question.Answer)
+	}
+
+	isComplete := len(unansweredQuestions) < wbClient.FetchLimit(marketplaces.Question)
+
+	for _, id := range pendingIds {
+		answer, isAnswered := answers[id]
+		if !isAnswered && (unansweredIds[id] || !isComplete) {
+			continue
+		}
+
+		if err := monitor.notifier.MarkWBQuestionAnswered(ctx, account, id, answer); err != nil {
+			logger.Error("Failed to update notification for answered question", "itemId", id, "error", err)
+		} else {
+			logger.Info("Updated notification for answered question", "itemId", id)
+		}
+	}
+}
+
+func (monitor *Monitor) checkForAnsweredFeedbacks(ctx context.Context, wbClient *client.WBClient, unansweredFeedbacks []wb.Feedback) {
+	account := wbClient.Name()
+
+	pendingIds := monitor.unansweredWBIds(account, marketplaces.Feedback)
+	if len(pendingIds) == 0 {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	logger.Info("Checking for answered feedbacks")
+
+	answeredFeedbacks, err := wbClient.FetchAnsweredFeedbacks()
+	if err != nil {
+		logger.Error("Failed to check for answered feedbacks", "error", err)
+		return
+	}
+
+	unansweredIds := make(map[string]bool, len(unansweredFeedbacks))
+	for _, feedback := range unansweredFeedbacks {
+		unansweredIds[feedback.Id] = true
+	}
+
+	answers := make(map[string]string, len(answeredFeedbacks))
+	for _, feedback := range answeredFeedbacks {
+		answers[feedback.Id] = answerText(feedback.Answer)
+	}
+
+	isComplete := len(unansweredFeedbacks) < wbClient.FetchLimit(marketplaces.Feedback)
+
+	for _, id := range pendingIds {
+		answer, isAnswered := answers[id]
+		if !isAnswered && (unansweredIds[id] || !isComplete) {
+			continue
+		}
+
+		if err := monitor.notifier.MarkWBFeedbackAnswered(ctx, account, id, answer); err != nil {
+			logger.Error("Failed to update notification for answered feedback", "itemId", id, "error", err)
+		} else {
+			logger.Info("Updated notification for answered feedback", "itemId", id)
+		}
+	}
+}
+
+// checkForAnsweredYandexFeedbacks looks up the feedbacks of the account still
+// unanswered in the history, which are answered once they no longer need a
+// reaction, e.g. after an answer in the Yandex cabinet.
+func (monitor *Monitor) checkForAnsweredYandexFeedbacks(ctx context.Context, yandexClient *client.YandexClient) {
+	account := yandexClient.Name()
+
+	unanswered := false
+	reactions := make(map[int]store.Reaction)
+	for _, reaction := range monitor.store.AllReactions(store.ReactionFilter{Marketplace: "Yandex", Type: marketplaces.Feedback.String(), Answered: &unanswered}) {
+		if id, err := strconv.Atoi(reaction.ItemId); err == nil && reaction.Account == account {
+			reactions[id] = reaction
+		}
+	}
+
+	if len(reactions) == 0 {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	logger.Info("Checking for answered feedbacks")
+
+	feedbacks, err := yandexClient.FetchFeedbacks(yandexClient.BusinessId(), slices.Collect(maps.Keys(reactions)))
+	if err != nil {
+		logger.Error("Failed to check for answered feedbacks", "error", err)
+		return
+	}
+
+	for _, feedback := range feedbacks {
+		reaction, ok := reactions[feedback.Id]
+		if !ok || feedback.NeedReaction {
+			continue
+		}
+
+		if err := monitor.notifier.MarkReactionAnswered(ctx, reaction, ""); err != nil {
+			logger.Error("Failed to update notification for answered feedback", "itemId", feedback.Id, "error", err)
+		} else {
+			logger.Info("Updated notification for answered feedback", "itemId", feedback.Id)
+		}
+	}
+}
+
+func answerText(answer *wb.Answer) string {
+	if answer == nil {
+		return ""
+	}
+
+	return answer.Text
+}
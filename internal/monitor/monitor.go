@@ -2,13 +2,18 @@ package monitor
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"marketplace-notifications/internal/client"
 	"marketplace-notifications/internal/config"
-	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"marketplace-notifications/internal/metrics"
+	"marketplace-notifications/internal/store"
 	"marketplace-notifications/internal/telegram"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 )
@@ -16,99 +21,180 @@ import (
 type Monitor struct {
 	mutex                sync.RWMutex
 	isRunning            bool
+	startedAt            time.Time
+	startedBy            string
+	stoppedAt            time.Time
+	stoppedBy            string
 	lastCheck            time.Time
 	lastUpdateDiscovered time.Time
+	accountsMutex        sync.Mutex
+	accounts             map[string]*accountStatus
+	sourceStatus         map[string]*sourceStatus
+	tokenStatus          map[string]tokenStatus
 	config               *config.MonitorConfig
+	configUpdates        chan struct{}
+	yandexWakeup         chan struct{}
 	apiClient            *client.APIClient
 	notifier             *telegram.TelegramNotifier
+	store                *store.Store
 	ctx                  context.Context
 	cancel               context.CancelFunc
+	runs                 sync.WaitGroup
 }
 
-func NewMonitor(config *config.MonitorConfig, apiClent *client.APIClient, notifier *telegram.TelegramNotifier) *Monitor {
+func NewMonitor(config *config.MonitorConfig, apiClent *client.APIClient, notifier *telegram.TelegramNotifier, store *store.Store) *Monitor {
 	return &Monitor{
-		config:    config,
-		apiClient: apiClent,
-		notifier:  notifier,
+		accounts:      make(map[string]*accountStatus),
+		sourceStatus:  make(map[string]*sourceStatus),
+		tokenStatus:   make(map[string]tokenStatus),
+		config:        config,
+		configUpdates: make(chan struct{}, 1),
+		yandexWakeup:  make(chan struct{}, 1),
+		apiClient:     apiClent,
+		notifier:      notifier,
+		store:         store,
 	}
 }
 
-func (monitor *Monitor) Start() {
+// Restore starts the monitor on boot if autostart is on or if it was last
+// asked to run before the restart.
+func (monitor *Monitor) Restore(ctx context.Context) {
+	if monitor.currentConfig().Autostart {
+		monitor.start(ctx, "autostart")
+		return
+	}
+
+	state, ok := monitor.store.MonitorState()
+	if !ok {
+		slog.Info("Monitor is stopped until started through the API, set AUTOSTART=true to start on boot")
+		return
+	}
+
+	if state.Running {
+		monitor.start(ctx, "restore")
+		return
+	}
+
+	monitor.mutex.Lock()
+	monitor.stoppedAt, monitor.stoppedBy = state.ChangedAt, state.ChangedBy
+	monitor.mutex.Unlock()
+
+	slog.Info("Monitor stays stopped as before the restart", "stoppedAt", state.ChangedAt, "stoppedBy", state.ChangedBy)
+}
+
+// Start runs the checks until Stop is called or ctx is done, and remembers
+// that the monitor should run after a restart.
+func (monitor *Monitor) Start(ctx context.Context, startedBy string) {
+	if !monitor.start(ctx, startedBy) {
+		return
+	}
+
+	if err := monitor.store.SetMonitorState(true, startedBy); err != nil {
+		slog.Error("Failed to save monitor state", "error", err)
+	}
+}
+
+func (monitor *Monitor) start(ctx context.Context, startedBy string) bool {
 	monitor.mutex.Lock()
 	defer monitor.mutex.Unlock()
 
 	if monitor.isRunning {
-		log.Println("[INFO] Already running")
-		return
+		slog.Info("Monitor is already running")
+		return false
 	}
 
-	monitor.ctx, monitor.cancel = context.WithCancel(context.Background())
+	monitor.ctx, monitor.cancel = context.WithCancel(ctx)
 	monitor.isRunning = true
+	monitor.startedAt = time.Now()
+	monitor.startedBy = startedBy
+
+	slog.Info("Starting monitor", "startedBy", startedBy)
 
-	log.Println("[INFO] Starting monitor...")
-	log.Printf("[INFO] Check interval: %s", monitor.config.CheckInterval)
+	runCtx := monitor.ctx
 
-	go monitor.run()
+	monitor.wakeYandexWorkers()
+
+	monitor.runs.Add(1)
+	go func() {
+		defer monitor.runs.Done()
+		monitor.run(runCtx)
+	}()
+
+	return true
 }
 
-func (monitor *Monitor) Stop() {
+// Stop stops the checks and remembers that the monitor should stay stopped
+// after a restart.
+func (monitor *Monitor) Stop(stoppedBy string) {
 	monitor.mutex.Lock()
 	defer monitor.mutex.Unlock()
 
-	if !monitor.isRunning {
-		log.Println("[INFO] Monitor is not running")
+	if !monitor.stop(stoppedBy) {
 		return
 	}
 
-	monitor.isRunning = false
-
-	if monitor.cancel != nil {
-		monitor.cancel()
+	if err := monitor.store.SetMonitorState(false, stoppedBy); err != nil {
+		slog.Error("Failed to save monitor state", "error", err)
 	}
 }
 
-func (monitor *Monitor) HandleYandexNotification(rawNotification json.RawMessage) error {
-	monitor.mutex.Lock()
-	defer monitor.mutex.Unlock()
-
+// stop must be called with the write lock held.
+func (monitor *Monitor) stop(stoppedBy string) bool {
 	if !monitor.isRunning {
-		log.Println("[INFO] Monitor is not running")
-		return fmt.Errorf("monitor is not running")
+		slog.Info("Monitor is not running")
+		return false
 	}
 
-	var notificationBase yandex.NotificationBase
+	monitor.isRunning = false
+	monitor.stoppedAt = time.Now()
+	monitor.stoppedBy = stoppedBy
 
-	if err := json.Unmarshal(rawNotification, &notificationBase); err != nil {
-		log.Println("[ERROR] Failed to unmarshal new Yandex notification")
-		return fmt.Errorf("failed to unmarshal Yandex notification: %w", err)
+	if monitor.cancel != nil {
+		monitor.cancel()
 	}
 
-	switch notificationBase.NotificationType {
-	case "GOODS_FEEDBACK_CREATED":
-		var feedbackNotification yandex.FeedbackNotification
-		if err := json.Unmarshal(rawNotification, &feedbackNotification); err != nil {
-			log.Println("[ERROR] Failed to parse new Yandex feedback notification")
-			return fmt.Errorf("failed to parse Yandex feedback notification: %w", err)
-		}
+	return true
+}
 
-		log.Printf("[INFO] New Yandex feedback notification (id: %d)", feedbackNotification.FeedbackId)
+// Shutdown stops the monitor and waits for the checks in progress, including
+// their Telegram sends, to finish. The saved running state is kept so that
+// the monitor comes back the same way.
+func (monitor *Monitor) Shutdown(ctx context.Context) error {
+	monitor.mutex.Lock()
+	monitor.stop("shutdown")
+	monitor.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		monitor.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("checks still in progress: %w", ctx.Err())
+	}
+}
 
-		var feedback yandex.Feedback
-		if err := monitor.apiClient.FetchYandexFeedback(feedbackNotification.BusinessId, feedbackNotification.FeedbackId, &feedback); err != nil {
-			log.Printf("[ERROR] Unable to fetch Yandex feedback with id %d", feedbackNotification.FeedbackId)
-			return fmt.Errorf("unable to fetch Yandex feedback with id %d", feedbackNotification.FeedbackId)
-		}
+func (monitor *Monitor) UpdateConfig(config *config.MonitorConfig) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
 
-		monitor.lastUpdateDiscovered = feedback.CreatedDate
+	monitor.config = config
 
-		if err := monitor.notifier.SendYandexFeedbackNotificationToAllChats(feedback); err != nil {
-			log.Printf("[ERROR] Failed to send notification for feedback with id %d: %v", feedback.Id, err)
-		} else {
-			log.Printf("[INFO] Sent feedback notification with id %d", feedback.Id)
-		}
+	select {
+	case monitor.configUpdates <- struct{}{}:
+	default:
 	}
+}
+
+func (monitor *Monitor) currentConfig() *config.MonitorConfig {
+	monitor.mutex.RLock()
+	defer monitor.mutex.RUnlock()
 
-	return nil
+	return monitor.config
 }
 
 func (monitor *Monitor) IsRunning() bool {
@@ -119,77 +205,180 @@ func (monitor *Monitor) IsRunning() bool {
 }
 
 func (monitor *Monitor) GetInfo() map[string]any {
+	desiredState, _ := monitor.store.MonitorState()
+
 	monitor.mutex.RLock()
 	defer monitor.mutex.RUnlock()
 
+	monitor.accountsMutex.Lock()
+	defer monitor.accountsMutex.Unlock()
+
+	accounts := make([]accountStatus, 0, len(monitor.accounts))
+	for _, wbClient := range monitor.apiClient.WB {
+		accounts = append(accounts, monitor.accountStatus("WB", wbClient.Name()))
+	}
+	for _, yandexClient := range monitor.apiClient.Yandex {
+		accounts = append(accounts, monitor.accountStatus("Yandex", yandexClient.Name()))
+	}
+
 	return map[string]any{
 		"isRunning":            monitor.isRunning,
+		"startedAt":            monitor.startedAt,
+		"startedBy":            monitor.startedBy,
+		"stoppedAt":            monitor.stoppedAt,
+		"stoppedBy":            monitor.stoppedBy,
 		"lastCheck":            monitor.lastCheck,
 		"lastUpdateDiscovered": monitor.lastUpdateDiscovered,
+		"accounts":             accounts,
+		"sources":              monitor.sourceStatuses(),
+		"wbTokens":             monitor.tokenStatuses(),
+		"desiredState":         desiredState,
+		"yandexQueue":          monitor.store.YandexJobCounts(),
 	}
 }
 
-func (monitor *Monitor) run() {
-	ticker := time.NewTicker(monitor.config.CheckInterval)
+func (monitor *Monitor) run(ctx context.Context) {
+	for {
+		sourcesCtx, cancelSources := context.WithCancel(ctx)
+
+		var wg sync.WaitGroup
+		for _, source := range monitor.sources() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				monitor.runSource(sourcesCtx, source)
+			}()
+		}
+
+		select {
+		case <-monitor.configUpdates:
+			cancelSources()
+			wg.Wait()
+			slog.Info("Rescheduling sources after config update")
+		case <-ctx.Done():
+			cancelSources()
+			wg.Wait()
+			slog.Info("Monitor stopped")
+			return
+		}
+	}
+}
+
+// runSource checks the source until ctx is done. A check in progress is not
+// cancelled with ctx, Shutdown waits for it to finish.
+func (monitor *Monitor) runSource(ctx context.Context, source source) {
+	slog.Info("Scheduling source", "marketplace", source.marketplace, "account", source.account, "type", source.reactionType.String(), "kind", source.kind, "interval", source.interval)
+
+	ticker := time.NewTicker(source.interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			monitor.checkForUpdates()
-		case <-monitor.ctx.Done():
-			log.Println("[INFO] Monitor stopped")
+			checkCtx, _ := logging.WithCorrelationId(context.WithoutCancel(ctx))
+			checkCtx, _ = logging.With(checkCtx,
+				"marketplace", source.marketplace,
+				"account", source.account,
+				"type", source.reactionType.String(),
+				"kind", source.kind,
+			)
+
+			start := time.Now()
+			source.check(checkCtx)
+			metrics.CheckDuration.Observe(time.Since(start).Seconds(), source.marketplace, source.reactionType.String())
+		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (monitor *Monitor) checkForUpdates() {
-	log.Println("[INFO] Checking for questions...")
+func (monitor *Monitor) checkWBQuestions(ctx context.Context, wbClient *client.WBClient) {
+	account := wbClient.Name()
 
-	questions, questionsErr := monitor.apiClient.FetchWBQuestions()
-	if questionsErr != nil {
-		log.Printf("[ERROR] Failed to check for questions: %v", questionsErr)
+	logger := logging.FromContext(ctx)
+	logger.Info("Checking for questions")
+
+	questions, err := wbClient.FetchQuestions()
+	monitor.recordSourceCheck("WB", account, marketplaces.Question, pollCheck, err)
+	if err != nil {
+		logger.Error("Failed to check for questions", "error", err)
+		return
 	}
 
-	log.Println("[INFO] Checking for feedbacks...")
+	newQuestions := slices.DeleteFunc(slices.Clone(questions), func(question wb.Question) bool {
+		return monitor.isNotified(marketplaces.Question, question.Id)
+	})
+
+	logger.Info("Found new questions", "count", len(newQuestions), "unanswered", len(questions))
+	metrics.ItemsFound.Add(float64(len(newQuestions)), "WB", marketplaces.Question.String())
 
-	feedbacks, feedbacksErr := monitor.apiClient.FetchWBFeedbacks()
-	if feedbacksErr != nil {
-		log.Printf("[ERROR] Failed to check for feedbacks: %v", feedbacksErr)
+	if len(newQuestions) > 0 {
+		monitor.recordUpdateDiscovered("WB", account, time.Now())
+		monitor.sendSummaryNotification(ctx, "WB", account, marketplaces.Question, len(questions))
 	}
 
-	if questionsErr == nil && feedbacksErr == nil {
-		monitor.lastCheck = time.Now()
+	for _, question := range newQuestions {
+		if err := monitor.notifier.SendWBQuestionNotificationToAllChats(ctx, account, question); err != nil {
+			logger.Error("Failed to send question notification", "itemId", question.Id, "error", err)
+		} else {
+			logger.Info("Sent question notification", "itemId", question.Id)
+		}
 	}
 
-	newQuestionsNumber, newFeedbacksNumber := len(questions), len(feedbacks)
+	monitor.checkForAnsweredQuestions(ctx, wbClient, questions)
+}
 
-	log.Printf("[INFO] Found %d new questions and %d new feedbacks", newQuestionsNumber, newFeedbacksNumber)
+func (monitor *Monitor) checkWBFeedbacks(ctx context.Context, wbClient *client.WBClient) {
+	account := wbClient.Name()
 
-	if newQuestionsNumber > 0 || newFeedbacksNumber > 0 {
-		monitor.lastUpdateDiscovered = monitor.lastCheck
+	logger := logging.FromContext(ctx)
+	logger.Info("Checking for feedbacks")
 
-		if err := monitor.notifier.SendSummaryNotificationToAllChats(newQuestionsNumber, newFeedbacksNumber); err != nil {
-			log.Printf("[ERROR] Failed to send summary notification: %v", err)
-		} else {
-			log.Printf("[INFO] Summary notification sent")
-		}
+	feedbacks, err := wbClient.FetchFeedbacks()
+	monitor.recordSourceCheck("WB", account, marketplaces.Feedback, pollCheck, err)
+	if err != nil {
+		logger.Error("Failed to check for feedbacks", "error", err)
+		return
 	}
 
-	for _, question := range questions {
-		if err := monitor.notifier.SendWBQuestionNotificationToAllChats(question); err != nil {
-			log.Printf("[ERROR] Failed to send notification for question with id %s: %v", question.Id, err)
-		} else {
-			log.Printf("[INFO] Sent question notification with id %s", question.Id)
-		}
+	newFeedbacks := slices.DeleteFunc(slices.Clone(feedbacks), func(feedback wb.Feedback) bool {
+		return monitor.isNotified(marketplaces.Feedback, feedback.Id)
+	})
+
+	logger.Info("Found new feedbacks", "count", len(newFeedbacks), "unanswered", len(feedbacks))
+	metrics.ItemsFound.Add(float64(len(newFeedbacks)), "WB", marketplaces.Feedback.String())
+
+	if len(newFeedbacks) > 0 {
+		monitor.recordUpdateDiscovered("WB", account, time.Now())
+		monitor.sendSummaryNotification(ctx, "WB", account, marketplaces.Feedback, len(feedbacks))
 	}
 
-	for _, feedback := range feedbacks {
-		if err := monitor.notifier.SendWBFeedbackNotificationToAllChats(feedback); err != nil {
-			log.Printf("[ERROR] Failed to send notification for feedback with id %s: %v", feedback.Id, err)
+	// Negative reviews are sent first.
+	sort.SliceStable(newFeedbacks, func(i, j int) bool {
+		return monitor.notifier.IsNegativeWBFeedback(newFeedbacks[i]) && !monitor.notifier.IsNegativeWBFeedback(newFeedbacks[j])
+	})
+
+	for _, feedback := range newFeedbacks {
+		if err := monitor.notifier.SendWBFeedbackNotificationToAllChats(ctx, account, feedback); err != nil {
+			logger.Error("Failed to send feedback notification", "itemId", feedback.Id, "error", err)
 		} else {
-			log.Printf("[INFO] Sent feedback notification with id %s", feedback.Id)
+			logger.Info("Sent feedback notification", "itemId", feedback.Id)
 		}
 	}
+
+	monitor.checkForAnsweredFeedbacks(ctx, wbClient, feedbacks)
+}
+
+// isNotified reports whether a WB item was already notified about. Its
+// messages are edited once it is answered instead of being sent again.
+func (monitor *Monitor) isNotified(reactionType marketplaces.UserReactionType, itemId string) bool {
+	return monitor.store.IsNotified(store.ReactionId("WB", reactionType.String(), itemId))
+}
+
+func (monitor *Monitor) sendSummaryNotification(ctx context.Context, serviceName, account string, reactionType marketplaces.UserReactionType, number int) {
+	if err := monitor.notifier.SendSummaryNotificationToAllChats(ctx, serviceName, account, reactionType, number); err != nil {
+		logging.FromContext(ctx).Error("Failed to send summary notification", "error", err)
+	} else {
+		logging.FromContext(ctx).Info("Summary notification sent")
+	}
 }
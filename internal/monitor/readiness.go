@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"fmt"
+	"marketplace-notifications/internal/health"
+	"marketplace-notifications/internal/marketplaces"
+	"time"
+)
+
+// yandexFetchErrorExpiry is how long a failed Yandex webhook fetch fails the
+// readiness check.
+const yandexFetchErrorExpiry = 10 * time.Minute
+
+type sourceReadiness struct {
+	Source    string    `json:"source"`
+	Interval  string    `json:"interval"`
+	LastCheck time.Time `json:"lastCheck,omitzero"`
+	LastError string    `json:"lastError,omitempty"`
+	Ready     bool      `json:"ready"`
+}
+
+// CheckSourcesReadiness requires every polled source, WB checks and Yandex
+// polling or reconciliation, to have succeeded within maxMissedChecks of its
+// intervals.
+func (monitor *Monitor) CheckSourcesReadiness(maxMissedChecks int) health.Component {
+	monitor.mutex.RLock()
+	isRunning, startedAt := monitor.isRunning, monitor.startedAt
+	monitor.mutex.RUnlock()
+
+	if !isRunning {
+		return health.Ok("monitor is not running", nil)
+	}
+
+	sources := monitor.sources()
+	if len(sources) == 0 {
+		return health.Ok("no sources are polled", nil)
+	}
+
+	monitor.accountsMutex.Lock()
+	defer monitor.accountsMutex.Unlock()
+
+	var details []sourceReadiness
+	var failed int
+
+	for _, source := range sources {
+		status := monitor.sourceStatus[sourceKey(source.marketplace, source.account, source.reactionType, source.kind)]
+
+		readiness := sourceReadiness{
+			Source:   source.name(),
+			Interval: source.interval.String(),
+		}
+
+		deadline := time.Duration(maxMissedChecks) * source.interval
+		lastSuccess := startedAt
+		if status != nil {
+			readiness.LastCheck = status.LastCheck
+			readiness.LastError = status.LastError
+			if status.LastCheck.After(lastSuccess) {
+				lastSuccess = status.LastCheck
+			}
+		}
+
+		readiness.Ready = time.Since(lastSuccess) <= deadline
+		if !readiness.Ready {
+			failed++
+		}
+
+		details = append(details, readiness)
+	}
+
+	if failed > 0 {
+		return health.Fail(fmt.Sprintf("%d of %d sources have not polled successfully in time", failed, len(sources)), details)
+	}
+
+	return health.Ok("", details)
+}
+
+// CheckYandexReadiness fails while the last feedback fetch for a Yandex
+// webhook has failed, for at most yandexFetchErrorExpiry: with the webhook
+// alone, the next fetch only happens once Yandex reaches a ready instance.
+// Polling and reconciliation are covered by CheckSourcesReadiness.
+func (monitor *Monitor) CheckYandexReadiness() health.Component {
+	if !monitor.currentConfig().Yandex.WebhookEnabled() {
+		return health.Ok("Yandex webhook is disabled", nil)
+	}
+
+	monitor.accountsMutex.Lock()
+	defer monitor.accountsMutex.Unlock()
+
+	var details []sourceStatus
+	var failed int
+
+	for _, yandexClient := range monitor.apiClient.Yandex {
+		status, ok := monitor.sourceStatus[sourceKey("Yandex", yandexClient.Name(), marketplaces.Feedback, webhookCheck)]
+		if !ok {
+			continue
+		}
+
+		if status.LastError != "" && time.Since(status.LastErrorAt) < yandexFetchErrorExpiry {
+			failed++
+		}
+		details = append(details, *status)
+	}
+
+	if failed > 0 {
+		return health.Fail("last Yandex feedback fetch failed", details)
+	}
+
+	if len(details) == 0 {
+		return health.Ok("no Yandex feedbacks received yet", nil)
+	}
+
+	return health.Ok("", details)
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/metrics"
+	"marketplace-notifications/internal/store"
+	"strconv"
+)
+
+// AnswerReaction posts the answer to the marketplace with the account that
+// received the reaction, then marks its notifications answered.
+func (monitor *Monitor) AnswerReaction(ctx context.Context, reaction store.Reaction, text string) error {
+	logger := logging.FromContext(ctx).With("marketplace", reaction.Marketplace, "account", reaction.Account, "type", reaction.Type, "itemId", reaction.ItemId)
+
+	err := monitor.postAnswer(reaction, text)
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.AnswersPosted.Inc(reaction.Marketplace, reaction.Type, result)
+
+	if err != nil {
+		logger.Error("Failed to post answer", "error", err)
+		return err
+	}
+
+	logger.Info("Posted answer")
+
+	if err := monitor.notifier.MarkReactionAnswered(ctx, reaction, text); err != nil {
+		logger.Error("Failed to update notification for answered reaction", "error", err)
+	}
+
+	return nil
+}
+
+func (monitor *Monitor) postAnswer(reaction store.Reaction, text string) error {
+	switch reaction.Marketplace {
+	case "WB":
+		wbClient, ok := monitor.apiClient.WBClientByName(reaction.Account)
+		if !ok {
+			return fmt.Errorf("no WB account %q configured", reaction.Account)
+		}
+
+		if reaction.Type == marketplaces.Question.String() {
+			return wbClient.AnswerQuestion(reaction.ItemId, text)
+		}
+		return wbClient.AnswerFeedback(reaction.ItemId, text)
+
+	case "Yandex":
+		yandexClient, ok := monitor.apiClient.YandexClientByName(reaction.Account)
+		if !ok {
+			return fmt.Errorf("no Yandex account %q configured", reaction.Account)
+		}
+		if yandexClient.BusinessId() == 0 {
+			return fmt.Errorf("Yandex account %q needs a business id to answer feedbacks", reaction.Account)
+		}
+
+		feedbackId, err := strconv.Atoi(reaction.ItemId)
+		if err != nil {
+			return fmt.Errorf("invalid Yandex feedback id %q", reaction.ItemId)
+		}
+		return yandexClient.AnswerFeedback(yandexClient.BusinessId(), feedbackId, text)
+
+	default:
+		return fmt.Errorf("unknown marketplace %q", reaction.Marketplace)
+	}
+}
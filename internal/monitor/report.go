@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/report"
+	"marketplace-notifications/internal/store"
+	"time"
+)
+
+const reportCheckInterval = time.Minute
+
+// RunReports sends the rating reports once they are due, whether or not the
+// monitor is running. A report missed while the service was down goes out as
+// soon as it is back the same day.
+func (monitor *Monitor) RunReports(ctx context.Context) {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		monitor.sendDueReports(ctx, time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (monitor *Monitor) sendDueReports(ctx context.Context, now time.Time) {
+	config := monitor.currentConfig().Report
+	if !config.Enabled() || now.Before(config.DueAt(now)) {
+		return
+	}
+
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	if config.Daily {
+		monitor.sendReport(ctx, report.Daily, today)
+	}
+	if config.Weekly && now.Weekday() == config.WeeklyOn() {
+		monitor.sendReport(ctx, report.Weekly, today)
+	}
+}
+
+// sendReport sends the report of the kind for the period ending at to, once.
+func (monitor *Monitor) sendReport(ctx context.Context, kind report.Kind, to time.Time) {
+	key := fmt.Sprintf("report/%s/%s", kind, to.Format(time.DateOnly))
+	if monitor.store.AlertSent(key) {
+		return
+	}
+
+	ctx, logger := logging.WithCorrelationId(ctx)
+	logger = logger.With("report", kind)
+
+	config := monitor.currentConfig().Report
+	reactions := monitor.store.AllReactions(store.ReactionFilter{
+		From: report.Period(kind, report.Period(kind, to)),
+		To:   to,
+	})
+	ratingReport := report.Build(kind, to, reactions)
+
+	if err := monitor.notifier.SendRatingReport(ctx, config.ChatId, config.ThreadId, ratingReport); err != nil {
+		logger.Error("Failed to send rating report", "error", err)
+		return
+	}
+
+	if err := monitor.store.MarkAlertSent(key); err != nil {
+		logger.Error("Failed to save rating report", "key", key, "error", err)
+	}
+
+	logger.Info("Sent rating report", "from", ratingReport.From, "to", to, "feedbacks", ratingReport.Current.TotalFeedbacks())
+}
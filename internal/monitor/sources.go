@@ -0,0 +1,156 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/marketplaces"
+	"sort"
+	"time"
+)
+
+// Kinds of checks of a source, each with its own status.
+const (
+	pollCheck      = "poll"
+	reconcileCheck = "reconcile"
+	webhookCheck   = "webhook"
+)
+
+type source struct {
+	marketplace  string
+	account      string
+	reactionType marketplaces.UserReactionType
+	kind         string
+	interval     time.Duration
+	check        func(ctx context.Context)
+}
+
+type sourceStatus struct {
+	Marketplace string    `json:"marketplace"`
+	Account     string    `json:"account"`
+	Type        string    `json:"type"`
+	Kind        string    `json:"kind"`
+	LastCheck   time.Time `json:"lastCheck"`
+	LastError   string    `json:"lastError,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt,omitzero"`
+}
+
+func sourceKey(marketplace, account string, reactionType marketplaces.UserReactionType, kind string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", marketplace, account, reactionType, kind)
+}
+
+func (source source) name() string {
+	name := fmt.Sprintf("%s %s of account %s", source.marketplace, source.reactionType, source.account)
+	if source.kind != pollCheck {
+		name += fmt.Sprintf(" (%s)", source.kind)
+	}
+
+	return name
+}
+
+// sources lists the polled sources enabled by the current config, each of
+// which runs on its own schedule.
+func (monitor *Monitor) sources() []source {
+	config := monitor.currentConfig()
+
+	var sources []source
+
+	for _, wbClient := range monitor.apiClient.WB {
+		if config.WB.QuestionsEnabled() {
+			sources = append(sources, source{
+				marketplace:  "WB",
+				account:      wbClient.Name(),
+				reactionType: marketplaces.Question,
+				kind:         pollCheck,
+				interval:     config.WB.Questions.IntervalOr(config.CheckInterval),
+				check:        func(ctx context.Context) { monitor.checkWBQuestions(ctx, wbClient) },
+			})
+		}
+
+		if config.WB.FeedbacksEnabled() {
+			sources = append(sources, source{
+				marketplace:  "WB",
+				account:      wbClient.Name(),
+				reactionType: marketplaces.Feedback,
+				kind:         pollCheck,
+				interval:     config.WB.Feedbacks.IntervalOr(config.CheckInterval),
+				check:        func(ctx context.Context) { monitor.checkWBFeedbacks(ctx, wbClient) },
+			})
+		}
+	}
+
+	for _, yandexClient := range monitor.apiClient.Yandex {
+		if config.Yandex.PollingEnabled() {
+			sources = append(sources, source{
+				marketplace:  "Yandex",
+				account:      yandexClient.Name(),
+				reactionType: marketplaces.Feedback,
+				kind:         pollCheck,
+				interval:     config.Yandex.Feedbacks.IntervalOr(config.CheckInterval),
+				check:        func(ctx context.Context) { monitor.pollYandexFeedbacks(ctx, yandexClient) },
+			})
+		}
+
+		// Listing needs the business id, which webhooks don't.
+		if config.Yandex.ReconcileEnabled() && yandexClient.BusinessId() != 0 {
+			sources = append(sources, source{
+				marketplace:  "Yandex",
+				account:      yandexClient.Name(),
+				reactionType: marketplaces.Feedback,
+				kind:         reconcileCheck,
+				interval:     config.Yandex.Reconcile.Interval,
+				check:        func(ctx context.Context) { monitor.reconcileYandexFeedbacks(ctx, yandexClient) },
+			})
+		}
+	}
+
+	return sources
+}
+
+func (monitor *Monitor) recordSourceCheck(marketplace, account string, reactionType marketplaces.UserReactionType, kind string, err error) {
+	if err == nil {
+		monitor.recordCheck(marketplace, account)
+	}
+
+	monitor.accountsMutex.Lock()
+	defer monitor.accountsMutex.Unlock()
+
+	key := sourceKey(marketplace, account, reactionType, kind)
+
+	status, ok := monitor.sourceStatus[key]
+	if !ok {
+		status = &sourceStatus{Marketplace: marketplace, Account: account, Type: reactionType.String(), Kind: kind}
+		monitor.sourceStatus[key] = status
+	}
+
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastErrorAt = time.Now()
+		return
+	}
+
+	status.LastCheck = time.Now()
+	status.LastError = ""
+}
+
+// sourceStatuses must be called with accountsMutex held.
+func (monitor *Monitor) sourceStatuses() []sourceStatus {
+	statuses := make([]sourceStatus, 0, len(monitor.sourceStatus))
+	for _, status := range monitor.sourceStatus {
+		statuses = append(statuses, *status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Marketplace != statuses[j].Marketplace {
+			return statuses[i].Marketplace < statuses[j].Marketplace
+		}
+		if statuses[i].Account != statuses[j].Account {
+			return statuses[i].Account < statuses[j].Account
+		}
+		if statuses[i].Type != statuses[j].Type {
+			return statuses[i].Type < statuses[j].Type
+		}
+		return statuses[i].Kind < statuses[j].Kind
+	})
+
+	return statuses
+}
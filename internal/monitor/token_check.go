@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"marketplace-notifications/internal/client"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"slices"
+	"strings"
+	"time"
+)
+
+type tokenStatus struct {
+	Account   string    `json:"account"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	DaysLeft  int       `json:"daysLeft"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	ReadOnly  bool      `json:"readOnly"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// RunTokenChecks inspects the WB tokens right away and then on the configured
+// schedule, whether or not the monitor is running.
+func (monitor *Monitor) RunTokenChecks(ctx context.Context) {
+	for {
+		monitor.checkWBTokens()
+
+		select {
+		case <-time.After(monitor.currentConfig().TokenCheck.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (monitor *Monitor) checkWBTokens() {
+	for _, wbClient := range monitor.apiClient.WB {
+		monitor.checkWBToken(wbClient)
+	}
+}
+
+func (monitor *Monitor) checkWBToken(wbClient *client.WBClient) {
+	account := wbClient.Name()
+	config := monitor.currentConfig()
+
+	info, err := wbClient.TokenInfo()
+	if err != nil {
+		slog.Error("Failed to inspect WB token", "marketplace", "WB", "account", account, "error", err)
+		monitor.setTokenStatus(tokenStatus{Account: account, Error: err.Error()})
+
+		// Keyed by a fingerprint of the token, so that a replaced token that
+		// is invalid too gets its own alert.
+		monitor.sendAlertOnce(fmt.Sprintf("wb-token/%s/invalid/%s", account, wbClient.TokenFingerprint()), func() error {
+			return monitor.notifier.SendWBTokenErrorAlert(account, err)
+		})
+		return
+	}
+
+	daysLeft := info.DaysLeft(time.Now())
+
+	slog.Info("Inspected WB token", "marketplace", "WB", "account", account, "expiresAt", info.ExpiresAt, "daysLeft", daysLeft, "scopes", info.Scopes)
+
+	monitor.setTokenStatus(tokenStatus{
+		Account:   account,
+		ExpiresAt: info.ExpiresAt,
+		DaysLeft:  daysLeft,
+		Scopes:    info.Scopes,
+		ReadOnly:  info.ReadOnly,
+	})
+
+	// Keys include the expiry so that a renewed token gets its own alerts.
+	keyPrefix := fmt.Sprintf("wb-token/%s/%d", account, info.ExpiresAt.Unix())
+
+	answering := monitor.notifier.AnsweringEnabled()
+	if missingScopes := missingWBScopes(config.WB.QuestionsEnabled() || config.WB.FeedbacksEnabled(), answering, info); len(missingScopes) > 0 {
+		slog.Warn("WB token lacks scopes", "marketplace", "WB", "account", account, "missingScopes", missingScopes)
+
+		// Keyed by the missing scopes, so that enabling a feature later alerts
+		// about what it lacks.
+		monitor.sendAlertOnce(keyPrefix+"/scopes/"+strings.Join(missingScopes, ","), func() error {
+			return monitor.notifier.SendWBTokenScopeAlert(account, missingScopes)
+		})
+	}
+
+	if threshold, ok := expiryThreshold(time.Until(info.ExpiresAt), config.TokenCheck.AlertDays); ok {
+		monitor.sendAlertOnce(fmt.Sprintf("%s/expiry/%d", keyPrefix, threshold), func() error {
+			return monitor.notifier.SendWBTokenExpiryAlert(account, info, daysLeft)
+		})
+	}
+}
+
+func (monitor *Monitor) sendAlertOnce(key string, send func() error) {
+	if monitor.store.AlertSent(key) {
+		return
+	}
+
+	if err := send(); err != nil {
+		slog.Error("Failed to send alert", "alert", key, "error", err)
+		return
+	}
+
+	if err := monitor.store.MarkAlertSent(key); err != nil {
+		slog.Error("Failed to save alert", "alert", key, "error", err)
+	}
+
+	slog.Info("Sent alert", "alert", key)
+}
+
+func (monitor *Monitor) setTokenStatus(status tokenStatus) {
+	monitor.accountsMutex.Lock()
+	defer monitor.accountsMutex.Unlock()
+
+	monitor.tokenStatus[status.Account] = status
+}
+
+// tokenStatuses must be called with accountsMutex held.
+func (monitor *Monitor) tokenStatuses() []tokenStatus {
+	statuses := make([]tokenStatus, 0, len(monitor.apiClient.WB))
+	for _, wbClient := range monitor.apiClient.WB {
+		if status, ok := monitor.tokenStatus[wbClient.Name()]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// missingWBScopes lists what the token lacks for the enabled features.
+// Answering from Telegram posts to WB, which read-only tokens can't.
+func missingWBScopes(polling, answering bool, info wb.TokenInfo) []string {
+	var missingScopes []string
+
+	if (polling || answering) && !info.HasScope(wb.FeedbacksAndQuestionsScope) {
+		missingScopes = append(missingScopes, wb.FeedbacksAndQuestionsScope)
+	}
+	if answering && info.ReadOnly {
+		missingScopes = append(missingScopes, wb.WriteScope)
+	}
+
+	return missingScopes
+}
+
+// expiryThreshold picks the smallest alert threshold, in days, the remaining
+// validity has already dropped below. An expired token maps to zero.
+func expiryThreshold(validFor time.Duration, alertDays []int) (int, bool) {
+	if validFor <= 0 {
+		return 0, true
+	}
+
+	thresholds := slices.Clone(alertDays)
+	slices.Sort(thresholds)
+
+	for _, days := range thresholds {
+		if validFor <= time.Duration(days)*24*time.Hour {
+			return days, true
+		}
+	}
+
+	return 0, false
+}
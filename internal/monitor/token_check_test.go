@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"marketplace-notifications/internal/marketplaces/wb"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMissingWBScopes(t *testing.T) {
+	full := wb.TokenInfo{Scopes: []string{wb.FeedbacksAndQuestionsScope}}
+	readOnly := wb.TokenInfo{Scopes: []string{wb.FeedbacksAndQuestionsScope}, ReadOnly: true}
+	noScopes := wb.TokenInfo{Scopes: []string{"content"}, ReadOnly: true}
+
+	tests := []struct {
+		name      string
+		polling   bool
+		answering bool
+		info      wb.TokenInfo
+		want      []string
+	}{
+		{name: "nothing enabled", info: noScopes, want: nil},
+		{name: "polling with scope", polling: true, info: full, want: nil},
+		{name: "polling without scope", polling: true, info: noScopes, want: []string{wb.FeedbacksAndQuestionsScope}},
+		{name: "polling read-only", polling: true, info: readOnly, want: nil},
+		{name: "answering", answering: true, info: full, want: nil},
+		{name: "answering read-only", polling: true, answering: true, info: readOnly, want: []string{wb.WriteScope}},
+		{name: "answering without scope", answering: true, info: noScopes, want: []string{wb.FeedbacksAndQuestionsScope, wb.WriteScope}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := missingWBScopes(test.polling, test.answering, test.info); !slices.Equal(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDaysLeftMatchesExpiryThreshold(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		validFor      time.Duration
+		wantDaysLeft  int
+		wantThreshold int
+		wantAlert     bool
+	}{
+		{validFor: 14*24*time.Hour + time.Hour, wantDaysLeft: 15, wantAlert: false},
+		{validFor: 7*24*time.Hour + time.Hour, wantDaysLeft: 8, wantThreshold: 14, wantAlert: true},
+		{validFor: 7 * 24 * time.Hour, wantDaysLeft: 7, wantThreshold: 7, wantAlert: true},
+		{validFor: 6*24*time.Hour + time.Hour, wantDaysLeft: 7, wantThreshold: 7, wantAlert: true},
+		{validFor: time.Hour, wantDaysLeft: 1, wantThreshold: 1, wantAlert: true},
+		{validFor: -time.Hour, wantDaysLeft: 0, wantThreshold: 0, wantAlert: true},
+	}
+
+	for _, test := range tests {
+		info := wb.TokenInfo{ExpiresAt: now.Add(test.validFor)}
+
+		if got := info.DaysLeft(now); got != test.wantDaysLeft {
+			t.Errorf("DaysLeft with %v left = %d, want %d", test.validFor, got, test.wantDaysLeft)
+		}
+
+		threshold, alert := expiryThreshold(test.validFor, []int{14, 7, 1})
+		if alert != test.wantAlert || threshold != test.wantThreshold {
+			t.Errorf("expiryThreshold(%v) = %d, %v; want %d, %v", test.validFor, threshold, alert, test.wantThreshold, test.wantAlert)
+		}
+	}
+}
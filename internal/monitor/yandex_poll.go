@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"context"
+	"marketplace-notifications/internal/client"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"time"
+)
+
+// pollYandexFeedbacks lists the feedbacks of the account created since the
+// watermark and queues the new ones, in place of the webhook.
+func (monitor *Monitor) pollYandexFeedbacks(ctx context.Context, yandexClient *client.YandexClient) {
+	account := yandexClient.Name()
+	businessId := yandexClient.BusinessId()
+
+	logger := logging.FromContext(ctx)
+
+	watermark, err := monitor.store.YandexPollWatermark(businessId)
+	if err != nil {
+		logger.Error("Failed to save Yandex poll watermark", "error", err)
+		return
+	}
+
+	logger.Info("Checking for feedbacks", "businessId", businessId, "from", watermark)
+
+	feedbacks, err := yandexClient.ListFeedbacks(businessId, watermark)
+	monitor.recordSourceCheck("Yandex", account, marketplaces.Feedback, pollCheck, err)
+	if err != nil {
+		logger.Error("Failed to check for feedbacks", "error", err)
+		return
+	}
+
+	// The listing includes the watermark itself, the queue skips what it has
+	// seen before.
+	queued, err := monitor.queueListedYandexFeedbacks(ctx, businessId, feedbacks, "Queued feedback")
+	if err != nil {
+		logger.Error("Failed to queue feedbacks", "error", err)
+		return
+	}
+
+	logger.Info("Found new feedbacks", "count", queued)
+
+	if queued > 0 {
+		monitor.sendSummaryNotification(ctx, "Yandex", account, marketplaces.Feedback, queued)
+	}
+
+	if next := latestYandexFeedback(feedbacks, watermark); next.After(watermark) {
+		if err := monitor.store.SetYandexPollWatermark(businessId, next); err != nil {
+			logger.Error("Failed to save Yandex poll watermark", "error", err)
+		}
+	}
+
+	monitor.checkForAnsweredYandexFeedbacks(ctx, yandexClient)
+}
+
+// latestYandexFeedback returns the newest creation time among the feedbacks,
+// capped at now in case of clock skew.
+func latestYandexFeedback(feedbacks []yandex.Feedback, latest time.Time) time.Time {
+	for _, feedback := range feedbacks {
+		if feedback.CreatedDate.After(latest) {
+			latest = feedback.CreatedDate
+		}
+	}
+
+	if now := time.Now(); latest.After(now) {
+		return now
+	}
+
+	return latest
+}
@@ -0,0 +1,338 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/metrics"
+	"marketplace-notifications/internal/store"
+	"sync"
+	"time"
+)
+
+const yandexFeedbackCreated = "GOODS_FEEDBACK_CREATED"
+
+// maxYandexRetryDelay caps the backoff between attempts of a Yandex job.
+const maxYandexRetryDelay = 24 * time.Hour
+
+var (
+	ErrInvalidNotification = errors.New("invalid notification")
+	ErrNotAccepting        = errors.New("notifications are ignored")
+)
+
+// EnqueueYandexNotification validates a webhook notification and queues it
+// durably, also while the monitor is stopped. The feedback is fetched and
+// sent later by RunYandexWorkers. While Yandex feedbacks are disabled or
+// polled, notifications are ignored with ErrNotAccepting.
+func (monitor *Monitor) EnqueueYandexNotification(ctx context.Context, rawNotification json.RawMessage) (err error) {
+	notificationType := "unknown"
+	result := "queued"
+	defer func() {
+		metrics.YandexWebhooks.Inc(notificationType, result)
+	}()
+
+	logger := logging.FromContext(ctx).With("marketplace", "Yandex")
+
+	monitor.mutex.RLock()
+	isRunning, schedule := monitor.isRunning, monitor.config.Yandex
+	monitor.mutex.RUnlock()
+
+	if !schedule.FeedbacksEnabled() {
+		result = "ignored"
+		logger.Info("Ignoring Yandex notification, Yandex feedbacks are disabled")
+		return fmt.Errorf("%w: Yandex feedbacks are disabled", ErrNotAccepting)
+	}
+
+	if schedule.PollingEnabled() {
+		result = "ignored"
+		logger.Info("Ignoring Yandex notification, Yandex feedbacks are polled")
+		return fmt.Errorf("%w: Yandex feedbacks are polled", ErrNotAccepting)
+	}
+
+	var notificationBase yandex.NotificationBase
+	if err := json.Unmarshal(rawNotification, &notificationBase); err != nil {
+		result = "invalid"
+		logger.Error("Failed to unmarshal Yandex notification", "error", err)
+		return fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	notificationType = notificationBase.NotificationType
+	logger = logger.With("notificationType", notificationType)
+
+	if notificationType != yandexFeedbackCreated {
+		result = "ignored"
+		logger.Info("Ignoring Yandex notification")
+		return nil
+	}
+
+	var feedbackNotification yandex.FeedbackNotification
+	if err := json.Unmarshal(rawNotification, &feedbackNotification); err != nil {
+		result = "invalid"
+		logger.Error("Failed to parse Yandex feedback notification", "error", err)
+		return fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	logger = logger.With("itemId", feedbackNotification.FeedbackId, "businessId", feedbackNotification.BusinessId)
+
+	if feedbackNotification.FeedbackId <= 0 || feedbackNotification.BusinessId <= 0 {
+		result = "invalid"
+		logger.Error("Yandex feedback notification without feedback or business id")
+		return fmt.Errorf("%w: missing feedbackId or businessId", ErrInvalidNotification)
+	}
+
+	if _, ok := monitor.apiClient.YandexClientForBusiness(feedbackNotification.BusinessId); !ok {
+		result = "invalid"
+		logger.Error("No Yandex account configured for business")
+		return fmt.Errorf("%w: no Yandex account configured for business %d", ErrInvalidNotification, feedbackNotification.BusinessId)
+	}
+
+	enqueued, err := monitor.store.EnqueueYandexJob(feedbackNotification.BusinessId, feedbackNotification.FeedbackId, logging.CorrelationId(ctx))
+	if err != nil {
+		result = "error"
+		logger.Error("Failed to queue Yandex feedback notification", "error", err)
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+
+	if !enqueued {
+		result = "duplicate"
+		logger.Info("Ignoring duplicate Yandex feedback notification")
+		return nil
+	}
+
+	if !isRunning {
+		result = "buffered"
+		logger.Info("Buffered Yandex feedback notification until the monitor starts")
+		return nil
+	}
+
+	logger.Info("Queued Yandex feedback notification")
+	monitor.wakeYandexWorkers()
+
+	return nil
+}
+
+func (monitor *Monitor) wakeYandexWorkers() {
+	select {
+	case monitor.yandexWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// RunYandexWorkers processes queued Yandex notifications, including the ones
+// left over from before a restart, until ctx is done. Nothing is processed
+// while the monitor is stopped. Jobs in progress are finished rather than
+// cancelled with ctx.
+func (monitor *Monitor) RunYandexWorkers(ctx context.Context) {
+	jobCtx := context.WithoutCancel(ctx)
+	jobs := make(chan store.YandexJob)
+
+	var inflightMutex sync.Mutex
+	inflight := make(map[string]bool)
+
+	var wg sync.WaitGroup
+	for range monitor.currentConfig().Yandex.Webhook.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				monitor.processYandexJob(jobCtx, job)
+
+				inflightMutex.Lock()
+				delete(inflight, job.Id)
+				inflightMutex.Unlock()
+			}
+		}()
+	}
+
+	defer wg.Wait()
+	defer close(jobs)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	wasRunning := false
+
+	for {
+		isRunning := monitor.IsRunning()
+		if isRunning && !wasRunning {
+			monitor.releaseBufferedYandexJobs(jobCtx)
+		}
+		wasRunning = isRunning
+
+		for _, job := range monitor.dueYandexJobs(isRunning) {
+			inflightMutex.Lock()
+			isInflight := inflight[job.Id]
+			inflight[job.Id] = true
+			inflightMutex.Unlock()
+
+			if isInflight {
+				continue
+			}
+
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-monitor.yandexWakeup:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (monitor *Monitor) dueYandexJobs(isRunning bool) []store.YandexJob {
+	if !isRunning {
+		return nil
+	}
+
+	return monitor.store.DueYandexJobs(time.Now())
+}
+
+// releaseBufferedYandexJobs runs when the monitor starts. Notifications that
+// arrived in the meantime are dropped when too old and summarized per account
+// when too many; the rest are left to the workers.
+func (monitor *Monitor) releaseBufferedYandexJobs(ctx context.Context) {
+	ctx, logger := logging.WithCorrelationId(ctx)
+	ctx, logger = logging.With(ctx, "marketplace", "Yandex")
+	config := monitor.currentConfig().Yandex.Webhook
+
+	var stale []string
+	buffered := make(map[string][]store.YandexJob)
+
+	for _, job := range monitor.store.DueYandexJobs(time.Now()) {
+		if job.Attempts > 0 {
+			continue
+		}
+
+		if config.MaxBufferAge > 0 && time.Since(job.ReceivedAt) > config.MaxBufferAge {
+			stale = append(stale, job.Id)
+			continue
+		}
+
+		if yandexClient, ok := monitor.apiClient.YandexClientForBusiness(job.BusinessId); ok {
+			buffered[yandexClient.Name()] = append(buffered[yandexClient.Name()], job)
+		}
+	}
+
+	if len(stale) > 0 {
+		logger.Info("Discarding buffered Yandex notifications older than the max age", "count", len(stale), "maxAge", config.MaxBufferAge)
+
+		if err := monitor.store.ResolveYandexJobs(stale, store.JobDiscarded); err != nil {
+			logger.Error("Failed to save Yandex jobs", "error", err)
+		}
+	}
+
+	if config.SummaryThreshold == 0 {
+		return
+	}
+
+	for account, jobs := range buffered {
+		if len(jobs) <= config.SummaryThreshold {
+			continue
+		}
+
+		accountLogger := logger.With("account", account, "count", len(jobs))
+
+		// Jobs come oldest first.
+		if err := monitor.notifier.SendBufferedSummaryNotificationToAllChats(ctx, "Yandex", account, marketplaces.Feedback, len(jobs), jobs[0].ReceivedAt); err != nil {
+			accountLogger.Error("Failed to send summary of buffered Yandex notifications, sending them one by one", "error", err)
+			continue
+		}
+
+		ids := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.Id)
+		}
+
+		if err := monitor.store.ResolveYandexJobs(ids, store.JobSummarized); err != nil {
+			accountLogger.Error("Failed to save Yandex jobs", "error", err)
+		}
+
+		accountLogger.Info("Summarized buffered Yandex notifications")
+	}
+}
+
+func (monitor *Monitor) processYandexJob(ctx context.Context, job store.YandexJob) {
+	ctx, logger := logging.With(ctx,
+		"correlationId", job.CorrelationId,
+		"marketplace", "Yandex",
+		"itemId", job.FeedbackId,
+		"businessId", job.BusinessId,
+		"attempt", job.Attempts+1,
+	)
+
+	yandexClient, ok := monitor.apiClient.YandexClientForBusiness(job.BusinessId)
+	if !ok {
+		monitor.failYandexJob(ctx, job, fmt.Errorf("no Yandex account configured for business %d", job.BusinessId), false)
+		return
+	}
+
+	ctx, logger = logging.With(ctx, "account", yandexClient.Name())
+
+	var feedback yandex.Feedback
+	err := yandexClient.FetchFeedback(job.BusinessId, job.FeedbackId, &feedback)
+	monitor.recordSourceCheck("Yandex", yandexClient.Name(), marketplaces.Feedback, webhookCheck, err)
+	if err != nil {
+		monitor.failYandexJob(ctx, job, fmt.Errorf("unable to fetch Yandex feedback: %w", err), true)
+		return
+	}
+
+	monitor.recordUpdateDiscovered("Yandex", yandexClient.Name(), feedback.CreatedDate)
+	metrics.ItemsFound.Inc("Yandex", marketplaces.Feedback.String())
+
+	// Only fails when no chat got the message, so retrying can't duplicate it.
+	if err := monitor.notifier.SendYandexFeedbackNotificationToAllChats(ctx, yandexClient.Name(), feedback); err != nil {
+		monitor.failYandexJob(ctx, job, fmt.Errorf("failed to send feedback notification: %w", err), true)
+		return
+	}
+
+	logger.Info("Sent feedback notification")
+	metrics.YandexJobs.Inc("done")
+
+	if err := monitor.store.CompleteYandexJob(job.Id); err != nil {
+		logger.Error("Failed to save Yandex job", "error", err)
+	}
+}
+
+func (monitor *Monitor) failYandexJob(ctx context.Context, job store.YandexJob, jobErr error, retry bool) {
+	logger := logging.FromContext(ctx)
+	config := monitor.currentConfig().Yandex.Webhook
+
+	attempts := job.Attempts + 1
+
+	var nextAttemptAt time.Time
+	if retry && attempts < config.MaxAttempts {
+		nextAttemptAt = time.Now().Add(yandexRetryDelay(config.RetryBackoff, attempts))
+
+		logger.Warn("Yandex job failed, will retry", "error", jobErr, "nextAttemptAt", nextAttemptAt)
+		metrics.YandexJobs.Inc("retry")
+	} else {
+		logger.Error("Yandex job failed for good", "error", jobErr)
+		metrics.YandexJobs.Inc("failed")
+	}
+
+	if err := monitor.store.FailYandexJob(job.Id, jobErr, nextAttemptAt); err != nil {
+		logger.Error("Failed to save Yandex job", "error", err)
+	}
+}
+
+// yandexRetryDelay doubles the backoff with every failed attempt, up to
+// maxYandexRetryDelay.
+func yandexRetryDelay(backoff time.Duration, attempts int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempts && delay < maxYandexRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxYandexRetryDelay)
+}
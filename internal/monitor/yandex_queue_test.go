@@ -0,0 +1,30 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestYandexRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first retry", backoff: 10 * time.Second, attempts: 1, want: 10 * time.Second},
+		{name: "doubles", backoff: 10 * time.Second, attempts: 2, want: 20 * time.Second},
+		{name: "doubles again", backoff: 10 * time.Second, attempts: 4, want: 80 * time.Second},
+		{name: "capped", backoff: 10 * time.Second, attempts: 20, want: maxYandexRetryDelay},
+		{name: "no overflow", backoff: time.Hour, attempts: 100, want: maxYandexRetryDelay},
+		{name: "backoff above the cap", backoff: 48 * time.Hour, attempts: 1, want: maxYandexRetryDelay},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := yandexRetryDelay(test.backoff, test.attempts); got != test.want {
+				t.Fatalf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
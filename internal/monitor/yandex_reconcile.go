@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/client"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/metrics"
+	"time"
+)
+
+// reconcileYandexFeedbacks lists the recent feedbacks of the account and
+// queues the ones no webhook delivered, so that they are notified like any
+// other.
+func (monitor *Monitor) reconcileYandexFeedbacks(ctx context.Context, yandexClient *client.YandexClient) {
+	logger := logging.FromContext(ctx)
+	businessId := yandexClient.BusinessId()
+
+	reconcileStart, err := monitor.store.YandexReconcileStart(businessId)
+	if err != nil {
+		logger.Error("Failed to save Yandex reconciliation start", "error", err)
+		return
+	}
+
+	from := time.Now().Add(-monitor.currentConfig().Yandex.Reconcile.Lookback)
+	if reconcileStart.After(from) {
+		from = reconcileStart
+	}
+
+	logger.Info("Reconciling feedbacks", "businessId", businessId, "from", from)
+
+	feedbacks, err := yandexClient.ListFeedbacks(businessId, from)
+	monitor.recordSourceCheck("Yandex", yandexClient.Name(), marketplaces.Feedback, reconcileCheck, err)
+	if err != nil {
+		logger.Error("Failed to list feedbacks", "error", err)
+		return
+	}
+
+	missed, err := monitor.queueListedYandexFeedbacks(ctx, businessId, feedbacks, "Queued feedback whose webhook never arrived")
+	if err != nil {
+		logger.Error("Failed to queue missed feedbacks", "error", err)
+	}
+
+	logger.Info("Reconciled feedbacks", "listed", len(feedbacks), "missed", missed)
+	metrics.YandexReconciled.Add(float64(missed))
+
+	monitor.checkForAnsweredYandexFeedbacks(ctx, yandexClient)
+}
+
+// queueListedYandexFeedbacks queues the listed feedbacks that were not seen
+// before for the workers to send, and counts them.
+func (monitor *Monitor) queueListedYandexFeedbacks(ctx context.Context, businessId int, feedbacks []yandex.Feedback, queuedMessage string) (int, error) {
+	var queued int
+	defer func() {
+		if queued > 0 {
+			monitor.wakeYandexWorkers()
+		}
+	}()
+
+	for _, feedback := range feedbacks {
+		enqueued, err := monitor.store.EnqueueYandexJob(businessId, feedback.Id, logging.CorrelationId(ctx))
+		if err != nil {
+			return queued, fmt.Errorf("failed to queue feedback %d: %w", feedback.Id, err)
+		}
+
+		if enqueued {
+			queued++
+			logging.FromContext(ctx).Info(queuedMessage, "itemId", feedback.Id)
+		}
+	}
+
+	return queued, nil
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/store"
+	"sort"
+	"time"
+)
+
+type Kind string
+
+const (
+	Daily  Kind = "daily"
+	Weekly Kind = "weekly"
+)
+
+const (
+	maxArticles         = 15
+	maxQuestionArticles = 5
+)
+
+// Report summarizes the feedbacks and questions created between From and To
+// and the period of the same length before it. It is built from the history,
+// which only has the items found unanswered, so ones answered before a check
+// saw them are left out.
+type Report struct {
+	Kind            Kind
+	From            time.Time
+	To              time.Time
+	Current         Stats
+	Previous        Stats
+	Articles        []ArticleStats
+	MoreArticles    int
+	QuestionedItems []ArticleQuestions
+}
+
+type Stats struct {
+	Feedbacks      map[string]int
+	Rated          int
+	RatingSum      int
+	Negative       int
+	ArticleRatings map[string]Rating
+}
+
+// Rating accumulates the stars of one article.
+type Rating struct {
+	Count int
+	Sum   int
+}
+
+func (rating Rating) Average() float64 {
+	if rating.Count == 0 {
+		return 0
+	}
+
+	return float64(rating.Sum) / float64(rating.Count)
+}
+
+type ArticleStats struct {
+	Article        string
+	ProductName    string
+	Rating         Rating
+	PreviousRating Rating
+}
+
+type ArticleQuestions struct {
+	Article     string
+	ProductName string
+	Questions   int
+}
+
+// Period returns the start of the period of the kind that ends at to.
+func Period(kind Kind, to time.Time) time.Time {
+	if kind == Weekly {
+		return to.AddDate(0, 0, -7)
+	}
+
+	return to.AddDate(0, 0, -1)
+}
+
+func (stats Stats) TotalFeedbacks() int {
+	var total int
+	for _, count := range stats.Feedbacks {
+		total += count
+	}
+
+	return total
+}
+
+func (stats Stats) Average() float64 {
+	return Rating{Count: stats.Rated, Sum: stats.RatingSum}.Average()
+}
+
+// NegativeShare is the share of rated feedbacks with one or two stars, in
+// percent.
+func (stats Stats) NegativeShare() float64 {
+	if stats.Rated == 0 {
+		return 0
+	}
+
+	return float64(stats.Negative) * 100 / float64(stats.Rated)
+}
+
+// Build computes the report of the kind for the period ending at to from the
+// reactions of both that period and the one before it.
+func Build(kind Kind, to time.Time, reactions []store.Reaction) Report {
+	from := Period(kind, to)
+	previousFrom := Period(kind, from)
+
+	report := Report{
+		Kind:     kind,
+		From:     from,
+		To:       to,
+		Current:  newStats(),
+		Previous: newStats(),
+	}
+
+	productNames := make(map[string]string)
+	questions := make(map[string]int)
+
+	for _, reaction := range reactions {
+		if reaction.CreatedAt.Before(previousFrom) || !reaction.CreatedAt.Before(to) {
+			continue
+		}
+
+		current := !reaction.CreatedAt.Before(from)
+		if current && reaction.Article != "" && productNames[reaction.Article] == "" {
+			productNames[reaction.Article] = reaction.ProductName
+		}
+
+		switch reaction.Type {
+		case marketplaces.Question.String():
+			if current && reaction.Article != "" {
+				questions[reaction.Article]++
+			}
+		case marketplaces.Feedback.String():
+			if current {
+				report.Current.add(reaction)
+			} else {
+				report.Previous.add(reaction)
+			}
+		}
+	}
+
+	for article, rating := range report.Current.ArticleRatings {
+		report.Articles = append(report.Articles, ArticleStats{
+			Article:        article,
+			ProductName:    productNames[article],
+			Rating:         rating,
+			PreviousRating: report.Previous.ArticleRatings[article],
+		})
+	}
+	sort.Slice(report.Articles, func(i, j int) bool {
+		if report.Articles[i].Rating.Count != report.Articles[j].Rating.Count {
+			return report.Articles[i].Rating.Count > report.Articles[j].Rating.Count
+		}
+		return report.Articles[i].Article < report.Articles[j].Article
+	})
+	if len(report.Articles) > maxArticles {
+		report.MoreArticles = len(report.Articles) - maxArticles
+		report.Articles = report.Articles[:maxArticles]
+	}
+
+	for article, count := range questions {
+		report.QuestionedItems = append(report.QuestionedItems, ArticleQuestions{
+			Article:     article,
+			ProductName: productNames[article],
+			Questions:   count,
+		})
+	}
+	sort.Slice(report.QuestionedItems, func(i, j int) bool {
+		if report.QuestionedItems[i].Questions != report.QuestionedItems[j].Questions {
+			return report.QuestionedItems[i].Questions > report.QuestionedItems[j].Questions
+		}
+		return report.QuestionedItems[i].Article < report.QuestionedItems[j].Article
+	})
+	if len(report.QuestionedItems) > maxQuestionArticles {
+		report.QuestionedItems = report.QuestionedItems[:maxQuestionArticles]
+	}
+
+	return report
+}
+
+func newStats() Stats {
+	return Stats{
+		Feedbacks:      make(map[string]int),
+		ArticleRatings: make(map[string]Rating),
+	}
+}
+
+func (stats *Stats) add(reaction store.Reaction) {
+	stats.Feedbacks[reaction.Marketplace]++
+
+	if reaction.Rating == 0 {
+		return
+	}
+
+	stats.Rated++
+	stats.RatingSum += reaction.Rating
+	if reaction.Rating <= 2 {
+		stats.Negative++
+	}
+
+	if reaction.Article != "" {
+		rating := stats.ArticleRatings[reaction.Article]
+		rating.Count++
+		rating.Sum += reaction.Rating
+		stats.ArticleRatings[reaction.Article] = rating
+	}
+}
@@ -0,0 +1,22 @@
+package store
+
+import (
+	"time"
+)
+
+func (store *Store) AlertSent(key string) bool {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	_, ok := store.data.Alerts[key]
+	return ok
+}
+
+func (store *Store) MarkAlertSent(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.data.Alerts[key] = time.Now()
+
+	return store.save()
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// historyFlushDelay batches the writes of the reaction history, which
+// changes on every notification.
+const historyFlushDelay = 5 * time.Second
+
+// history is kept in its own file next to the store file, so that saving it
+// doesn't hold up the rest of the store.
+type history struct {
+	Reactions     map[string]*Reaction `json:"reactions"`
+	Notifications map[string]string    `json:"notifications"`
+}
+
+func historyPath(path string) string {
+	extension := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, extension) + ".history" + extension
+}
+
+// openHistory loads the history file.
+func (store *Store) openHistory() error {
+	store.history = history{
+		Reactions:     make(map[string]*Reaction),
+		Notifications: make(map[string]string),
+	}
+
+	content, err := os.ReadFile(historyPath(store.path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &store.history); err != nil {
+		return fmt.Errorf("failed to parse history file: %w", err)
+	}
+	if store.history.Reactions == nil {
+		store.history.Reactions = make(map[string]*Reaction)
+	}
+	if store.history.Notifications == nil {
+		store.history.Notifications = make(map[string]string)
+	}
+
+	return nil
+}
+
+// historyChanged must be called with historyMutex held. The history is
+// written shortly after, together with the changes made in the meantime.
+func (store *Store) historyChanged() error {
+	if store.readOnly {
+		return errReadOnly
+	}
+	if store.historyClosed {
+		return fmt.Errorf("store is closed")
+	}
+
+	if store.historyFlush == nil {
+		store.historyFlush = time.AfterFunc(historyFlushDelay, store.flushHistory)
+	}
+
+	return nil
+}
+
+func (store *Store) flushHistory() {
+	store.historyMutex.Lock()
+	store.historyFlush = nil
+	store.historyMutex.Unlock()
+
+	if err := store.writeHistory(); err != nil {
+		slog.Error("Failed to save reaction history", "error", err)
+	}
+}
+
+// closeHistory writes the history one last time.
+func (store *Store) closeHistory() error {
+	store.historyMutex.Lock()
+	if store.historyClosed {
+		store.historyMutex.Unlock()
+		return nil
+	}
+
+	store.historyClosed = true
+	if store.historyFlush != nil {
+		store.historyFlush.Stop()
+		store.historyFlush = nil
+	}
+	store.historyMutex.Unlock()
+
+	return store.writeHistory()
+}
+
+func (store *Store) writeHistory() error {
+	store.historyWriteMutex.Lock()
+	defer store.historyWriteMutex.Unlock()
+
+	store.historyMutex.RLock()
+	content, err := json.Marshal(store.history)
+	store.historyMutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+
+	path := historyPath(store.path)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace history file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"time"
+)
+
+// MonitorState is the running state an operator last asked for, restored on
+// startup.
+type MonitorState struct {
+	Running   bool      `json:"running"`
+	ChangedAt time.Time `json:"changedAt"`
+	ChangedBy string    `json:"changedBy"`
+}
+
+func (store *Store) MonitorState() (MonitorState, bool) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	if store.data.Monitor == nil {
+		return MonitorState{}, false
+	}
+
+	return *store.data.Monitor, true
+}
+
+func (store *Store) SetMonitorState(running bool, changedBy string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.data.Monitor = &MonitorState{
+		Running:   running,
+		ChangedAt: time.Now(),
+		ChangedBy: changedBy,
+	}
+
+	return store.save()
+}
@@ -0,0 +1,326 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reactionRetention is how long received questions and feedbacks are kept in
+// the history.
+const reactionRetention = 90 * 24 * time.Hour
+
+// maxDeliveries caps the delivery history of a reaction.
+const maxDeliveries = 100
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type DeliveryAction string
+
+const (
+	DeliverySend  DeliveryAction = "send"
+	DeliveryEdit  DeliveryAction = "edit"
+	DeliveryPin   DeliveryAction = "pin"
+	DeliveryUnpin DeliveryAction = "unpin"
+)
+
+// Reaction is a received question or feedback, normalized across
+// marketplaces.
+type Reaction struct {
+	Id          string     `json:"id"`
+	Marketplace string     `json:"marketplace"`
+	Account     string     `json:"account"`
+	Type        string     `json:"type"`
+	ItemId      string     `json:"itemId"`
+	Rating      int        `json:"rating,omitempty"`
+	Priority    bool       `json:"priority,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Article     string     `json:"article,omitempty"`
+	ProductName string     `json:"productName,omitempty"`
+	OrderId     string     `json:"orderId,omitempty"`
+	Customer    string     `json:"customer,omitempty"`
+	Text        string     `json:"text,omitempty"`
+	Pros        string     `json:"pros,omitempty"`
+	Cons        string     `json:"cons,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ReceivedAt  time.Time  `json:"receivedAt"`
+	Answered    bool       `json:"answered"`
+	Answer      string     `json:"answer,omitempty"`
+	AnsweredAt  time.Time  `json:"answeredAt,omitzero"`
+	Deliveries  []Delivery `json:"deliveries,omitempty"`
+}
+
+// Delivery is one attempt to send or update the notification about a
+// reaction in a chat.
+type Delivery struct {
+	ChatId    string         `json:"chatId"`
+	Action    DeliveryAction `json:"action"`
+	MessageId int            `json:"messageId,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	At        time.Time      `json:"at"`
+}
+
+// ReactionFilter selects reactions, zero fields match everything. OldestFirst
+// reverses the order they are listed in.
+type ReactionFilter struct {
+	Marketplace string
+	Type        string
+	Ratings     []int
+	Article     string
+	Tag         string
+	From        time.Time
+	To          time.Time
+	Answered    *bool
+	OldestFirst bool
+}
+
+type ReactionPage struct {
+	Items      []Reaction `json:"items"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+func ReactionId(marketplace, reactionType, itemId string) string {
+	return fmt.Sprintf("%s:%s:%s", strings.ToLower(marketplace), reactionType, itemId)
+}
+
+// RecordReaction saves the latest content of a reaction and the body of its
+// notification, together with new deliveries of the notification.
+func (store *Store) RecordReaction(reaction Reaction, notification string, deliveries []Delivery) error {
+	store.historyMutex.Lock()
+	defer store.historyMutex.Unlock()
+
+	now := time.Now()
+
+	for id, existing := range store.history.Reactions {
+		if now.Sub(existing.ReceivedAt) > reactionRetention {
+			delete(store.history.Reactions, id)
+			delete(store.history.Notifications, id)
+		}
+	}
+
+	if existing, ok := store.history.Reactions[reaction.Id]; ok {
+		reaction.ReceivedAt = existing.ReceivedAt
+		reaction.Answered, reaction.Answer, reaction.AnsweredAt = existing.Answered, existing.Answer, existing.AnsweredAt
+		reaction.Deliveries = existing.Deliveries
+	} else {
+		reaction.ReceivedAt = now
+	}
+
+	reaction.Deliveries = appendDeliveries(reaction.Deliveries, deliveries)
+	store.history.Reactions[reaction.Id] = &reaction
+
+	if notification != "" {
+		store.history.Notifications[reaction.Id] = notification
+	}
+
+	return store.historyChanged()
+}
+
+// ReactionNotification returns the body of the notification about a
+// reaction, which is shown again once it is answered.
+func (store *Store) ReactionNotification(id string) string {
+	store.historyMutex.RLock()
+	defer store.historyMutex.RUnlock()
+
+	return store.history.Notifications[id]
+}
+
+// MarkReactionAnswered records the answer and the updates of the
+// notification. Reactions not in the history are ignored.
+func (store *Store) MarkReactionAnswered(id, answer string, deliveries []Delivery) error {
+	store.historyMutex.Lock()
+	defer store.historyMutex.Unlock()
+
+	reaction, ok := store.history.Reactions[id]
+	if !ok {
+		return nil
+	}
+
+	reaction.Answered = true
+	reaction.Answer = answer
+	reaction.AnsweredAt = time.Now()
+	reaction.Deliveries = appendDeliveries(reaction.Deliveries, deliveries)
+
+	return store.historyChanged()
+}
+
+// appendDeliveries adds deliveries to the history. Over the cap the oldest
+// ones are dropped, but not the messages sent and pinned, which are edited
+// and unpinned once the reaction is answered.
+func appendDeliveries(history, deliveries []Delivery) []Delivery {
+	history = append(history, deliveries...)
+
+	for len(history) > maxDeliveries {
+		i := slices.IndexFunc(history, func(delivery Delivery) bool {
+			return delivery.Error != "" || (delivery.Action != DeliverySend && delivery.Action != DeliveryPin)
+		})
+		if i < 0 {
+			i = 0
+		}
+
+		history = slices.Delete(history, i, i+1)
+	}
+
+	return history
+}
+
+// IsNotified reports whether a notification about the reaction got through
+// to a chat.
+func (store *Store) IsNotified(id string) bool {
+	store.historyMutex.RLock()
+	defer store.historyMutex.RUnlock()
+
+	reaction, ok := store.history.Reactions[id]
+
+	return ok && slices.ContainsFunc(reaction.Deliveries, func(delivery Delivery) bool {
+		return delivery.Action == DeliverySend && delivery.Error == ""
+	})
+}
+
+func (store *Store) Reaction(id string) (Reaction, bool) {
+	store.historyMutex.RLock()
+	defer store.historyMutex.RUnlock()
+
+	reaction, ok := store.history.Reactions[id]
+	if !ok {
+		return Reaction{}, false
+	}
+
+	return cloneReaction(reaction), true
+}
+
+// Reactions lists the reactions matching the filter, newest first unless
+// asked otherwise, limit at a time, which must be positive. The cursor of
+// the previous page continues after it. Delivery histories are left out.
+func (store *Store) Reactions(filter ReactionFilter, cursor string, limit int) (ReactionPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return ReactionPage{}, err
+	}
+
+	store.historyMutex.RLock()
+	defer store.historyMutex.RUnlock()
+
+	page := ReactionPage{Items: []Reaction{}}
+	for i, reaction := range store.matchingReactions(filter, after) {
+		if i == limit {
+			page.NextCursor = encodeCursor(page.Items[len(page.Items)-1])
+			break
+		}
+
+		page.Items = append(page.Items, reaction)
+	}
+
+	return page, nil
+}
+
+// AllReactions lists every reaction matching the filter in the order of
+// Reactions, without delivery histories.
+func (store *Store) AllReactions(filter ReactionFilter) []Reaction {
+	store.historyMutex.RLock()
+	defer store.historyMutex.RUnlock()
+
+	return store.matchingReactions(filter, nil)
+}
+
+// matchingReactions must be called with historyMutex held.
+func (store *Store) matchingReactions(filter ReactionFilter, after *Reaction) []Reaction {
+	listedBefore := func(reaction, other *Reaction) bool {
+		if filter.OldestFirst {
+			return reactionBefore(reaction, other)
+		}
+		return reactionBefore(other, reaction)
+	}
+
+	var matching []*Reaction
+	for _, reaction := range store.history.Reactions {
+		if filter.matches(reaction) && (after == nil || listedBefore(after, reaction)) {
+			matching = append(matching, reaction)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return listedBefore(matching[i], matching[j])
+	})
+
+	reactions := make([]Reaction, 0, len(matching))
+	for _, reaction := range matching {
+		item := *reaction
+		item.Deliveries = nil
+		reactions = append(reactions, item)
+	}
+
+	return reactions
+}
+
+func (filter ReactionFilter) matches(reaction *Reaction) bool {
+	switch {
+	case filter.Marketplace != "" && !strings.EqualFold(filter.Marketplace, reaction.Marketplace):
+		return false
+	case filter.Type != "" && filter.Type != reaction.Type:
+		return false
+	case len(filter.Ratings) > 0 && !slices.Contains(filter.Ratings, reaction.Rating):
+		return false
+	case filter.Article != "" && filter.Article != reaction.Article:
+		return false
+	case filter.Tag != "" && !slices.Contains(reaction.Tags, strings.ToLower(filter.Tag)):
+		return false
+	case !filter.From.IsZero() && reaction.CreatedAt.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && !reaction.CreatedAt.Before(filter.To):
+		return false
+	case filter.Answered != nil && *filter.Answered != reaction.Answered:
+		return false
+	}
+
+	return true
+}
+
+// reactionBefore orders reactions by creation time, then by id, so that the
+// order is total and a cursor always points between two items.
+func reactionBefore(reaction, other *Reaction) bool {
+	if !reaction.CreatedAt.Equal(other.CreatedAt) {
+		return reaction.CreatedAt.Before(other.CreatedAt)
+	}
+
+	return reaction.Id < other.Id
+}
+
+func encodeCursor(reaction Reaction) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(reaction.CreatedAt.Format(time.RFC3339Nano) + "|" + reaction.Id))
+}
+
+func decodeCursor(cursor string) (*Reaction, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(decoded), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	reaction := &Reaction{Id: id}
+	if reaction.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return reaction, nil
+}
+
+func cloneReaction(reaction *Reaction) Reaction {
+	clone := *reaction
+	clone.Deliveries = slices.Clone(reaction.Deliveries)
+	clone.Tags = slices.Clone(reaction.Tags)
+
+	return clone
+}
@@ -0,0 +1,353 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func recordTestReactions(t *testing.T, store *Store, reactions ...Reaction) {
+	t.Helper()
+
+	for _, reaction := range reactions {
+		if err := store.RecordReaction(reaction, "", nil); err != nil {
+			t.Fatalf("failed to record reaction: %v", err)
+		}
+	}
+}
+
+func testReaction(marketplace, itemId string, createdAt time.Time, rating int) Reaction {
+	return Reaction{
+		Id:          ReactionId(marketplace, "feedbacks", itemId),
+		Marketplace: marketplace,
+		Type:        "feedbacks",
+		ItemId:      itemId,
+		Rating:      rating,
+		CreatedAt:   createdAt,
+	}
+}
+
+func TestReactionsPagination(t *testing.T) {
+	store := openTestStore(t)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		recordTestReactions(t, store, testReaction("WB", fmt.Sprint(i), start.Add(time.Duration(i)*time.Hour), 5))
+	}
+	// Same creation time as item 2, ordered by id.
+	recordTestReactions(t, store, testReaction("Yandex", "2", start.Add(2*time.Hour), 1))
+
+	tests := []struct {
+		name   string
+		filter ReactionFilter
+		limit  int
+		want   [][]string
+	}{
+		{
+			name:  "newest first",
+			limit: 4,
+			want: [][]string{
+				{"wb:feedbacks:4", "wb:feedbacks:3", "yandex:feedbacks:2", "wb:feedbacks:2"},
+				{"wb:feedbacks:1", "wb:feedbacks:0"},
+			},
+		},
+		{
+			name:   "oldest first",
+			filter: ReactionFilter{OldestFirst: true},
+			limit:  3,
+			want: [][]string{
+				{"wb:feedbacks:0", "wb:feedbacks:1", "wb:feedbacks:2"},
+				{"yandex:feedbacks:2", "wb:feedbacks:3", "wb:feedbacks:4"},
+			},
+		},
+		{
+			name:   "filtered",
+			filter: ReactionFilter{Marketplace: "wb", From: start.Add(time.Hour), To: start.Add(4 * time.Hour)},
+			limit:  2,
+			want: [][]string{
+				{"wb:feedbacks:3", "wb:feedbacks:2"},
+				{"wb:feedbacks:1"},
+			},
+		},
+		{
+			name:   "by rating",
+			filter: ReactionFilter{Ratings: []int{1, 2}},
+			limit:  10,
+			want:   [][]string{{"yandex:feedbacks:2"}},
+		},
+		{
+			name:   "page ends exactly at the limit",
+			filter: ReactionFilter{Marketplace: "yandex"},
+			limit:  1,
+			want:   [][]string{{"yandex:feedbacks:2"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cursor string
+			for i, want := range test.want {
+				page, err := store.Reactions(test.filter, cursor, test.limit)
+				if err != nil {
+					t.Fatalf("page %d: unexpected error: %v", i+1, err)
+				}
+
+				var ids []string
+				for _, reaction := range page.Items {
+					ids = append(ids, reaction.Id)
+				}
+				if !reflect.DeepEqual(ids, want) {
+					t.Fatalf("page %d: got %v, want %v", i+1, ids, want)
+				}
+
+				if last := i == len(test.want)-1; last != (page.NextCursor == "") {
+					t.Fatalf("page %d: got next cursor %q", i+1, page.NextCursor)
+				}
+				cursor = page.NextCursor
+			}
+		})
+	}
+}
+
+func TestReactionsInvalidCursor(t *testing.T) {
+	store := openTestStore(t)
+
+	for _, cursor := range []string{"not base64!", "bm8tc2VwYXJhdG9y", "bm90LWEtdGltZXxpZA"} {
+		if _, err := store.Reactions(ReactionFilter{}, cursor, 10); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: got error %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestAppendDeliveries(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sent := func(chatId string, messageId int) Delivery {
+		return Delivery{ChatId: chatId, Action: DeliverySend, MessageId: messageId, At: at}
+	}
+	failed := func(chatId string) Delivery {
+		return Delivery{ChatId: chatId, Action: DeliverySend, Error: "blocked", At: at}
+	}
+	edited := Delivery{ChatId: "1", Action: DeliveryEdit, MessageId: 10, At: at}
+
+	tests := []struct {
+		name       string
+		history    []Delivery
+		deliveries []Delivery
+		want       []Delivery
+	}{
+		{
+			name:       "first send",
+			deliveries: []Delivery{sent("1", 10), sent("2", 20)},
+			want:       []Delivery{sent("1", 10), sent("2", 20)},
+		},
+		{
+			name:       "every send is kept",
+			history:    []Delivery{sent("1", 10), sent("2", 20)},
+			deliveries: []Delivery{sent("1", 11)},
+			want:       []Delivery{sent("1", 10), sent("2", 20), sent("1", 11)},
+		},
+		{
+			name:       "failures are kept",
+			history:    []Delivery{sent("1", 10)},
+			deliveries: []Delivery{failed("2")},
+			want:       []Delivery{sent("1", 10), failed("2")},
+		},
+		{
+			name:       "edits are appended",
+			history:    []Delivery{sent("1", 10)},
+			deliveries: []Delivery{edited, edited},
+			want:       []Delivery{sent("1", 10), edited, edited},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := appendDeliveries(test.history, test.deliveries); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAppendDeliveriesCapped(t *testing.T) {
+	history := []Delivery{
+		{ChatId: "1", Action: DeliverySend, Error: "blocked"},
+		{ChatId: "1", Action: DeliverySend, MessageId: 1},
+		{ChatId: "1", Action: DeliveryPin, MessageId: 1},
+	}
+	for i := range maxDeliveries + 10 {
+		history = appendDeliveries(history, []Delivery{{ChatId: "1", Action: DeliveryEdit, MessageId: 100 + i}})
+	}
+
+	if len(history) != maxDeliveries {
+		t.Fatalf("got %d deliveries, want %d", len(history), maxDeliveries)
+	}
+	if history[0].Action != DeliverySend || history[0].Error != "" || history[1].Action != DeliveryPin {
+		t.Fatalf("the sent and pinned messages were dropped: %+v", history[:2])
+	}
+	if history[2].MessageId != 112 {
+		t.Fatalf("oldest kept edit is %d, want 112", history[2].MessageId)
+	}
+}
+
+func TestMarkReactionAnswered(t *testing.T) {
+	store := openTestStore(t)
+
+	reaction := testReaction("WB", "1", time.Now(), 5)
+	if err := store.RecordReaction(reaction, "body", []Delivery{{ChatId: "1", Action: DeliverySend, MessageId: 10}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := store.MarkReactionAnswered(reaction.Id, "thanks", []Delivery{{ChatId: "1", Action: DeliveryEdit, MessageId: 10}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.MarkReactionAnswered("wb:feedbacks:unknown", "thanks", nil); err != nil {
+		t.Fatalf("unknown reactions must be ignored, got %v", err)
+	}
+
+	// Recording the reaction again keeps the answer and the deliveries.
+	if err := store.RecordReaction(reaction, "", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, ok := store.Reaction(reaction.Id)
+	if !ok {
+		t.Fatal("reaction not found")
+	}
+	if !got.Answered || got.Answer != "thanks" || len(got.Deliveries) != 2 {
+		t.Fatalf("got %+v, want it answered with a send and an edit", got)
+	}
+	if notification := store.ReactionNotification(reaction.Id); notification != "body" {
+		t.Fatalf("got notification %q, want %q", notification, "body")
+	}
+
+	unanswered := false
+	if page, _ := store.Reactions(ReactionFilter{Answered: &unanswered}, "", 10); len(page.Items) != 0 {
+		t.Fatalf("got %d unanswered reactions, want 0", len(page.Items))
+	}
+}
+
+func TestIsNotified(t *testing.T) {
+	store := openTestStore(t)
+
+	tests := []struct {
+		name       string
+		itemId     string
+		deliveries []Delivery
+		want       bool
+	}{
+		{name: "sent", itemId: "1", deliveries: []Delivery{{ChatId: "1", Action: DeliverySend, MessageId: 10}}, want: true},
+		{name: "failed", itemId: "2", deliveries: []Delivery{{ChatId: "1", Action: DeliverySend, Error: "blocked"}}, want: false},
+		{name: "no chats", itemId: "3", want: false},
+		{
+			name:   "sent to one of two chats",
+			itemId: "4",
+			deliveries: []Delivery{
+				{ChatId: "1", Action: DeliverySend, Error: "blocked"},
+				{ChatId: "2", Action: DeliverySend, MessageId: 20},
+			},
+			want: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reaction := testReaction("WB", test.itemId, time.Now(), 5)
+			if err := store.RecordReaction(reaction, "", test.deliveries); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := store.IsNotified(reaction.Id); got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if store.IsNotified("wb:feedbacks:unknown") {
+		t.Fatal("unknown reactions must not be notified")
+	}
+}
+
+func TestHistoryPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	recordTestReactions(t, store, testReaction("WB", "1", time.Now(), 4))
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	if err := store.RecordReaction(testReaction("WB", "2", time.Now(), 4), "", nil); err == nil {
+		t.Fatal("expected changes after Close to fail")
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+
+	if _, ok := reopened.Reaction(ReactionId("WB", "feedbacks", "1")); !ok {
+		t.Fatal("reaction was not written to the history file")
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	recordTestReactions(t, store, testReaction("WB", "1", time.Now(), 4))
+	if err := store.SetSubscriptionStatus("1", "", SubscriptionActive, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range []string{path, historyPath(path)} {
+		if files[file], err = os.ReadFile(file); err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+	}
+
+	readOnly, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("failed to open store read-only: %v", err)
+	}
+
+	if _, ok := readOnly.Reaction(ReactionId("WB", "feedbacks", "1")); !ok {
+		t.Fatal("reaction was not read from the history file")
+	}
+	if err := readOnly.RecordReaction(testReaction("WB", "2", time.Now(), 4), "", nil); err == nil || err.Error() != "store is read-only" {
+		t.Fatalf("got error %v, want the store to be read-only", err)
+	}
+	if err := readOnly.SetSubscriptionStatus("2", "", SubscriptionActive, ""); err == nil || err.Error() != "store is read-only" {
+		t.Fatalf("got error %v, want the store to be read-only", err)
+	}
+	if err := readOnly.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for file, content := range files {
+		if written, _ := os.ReadFile(file); !bytes.Equal(written, content) {
+			t.Fatalf("%s was written", file)
+		}
+	}
+
+	if _, err := OpenReadOnly(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected a missing store file to fail")
+	}
+}
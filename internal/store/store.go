@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"marketplace-notifications/internal/templates"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var errReadOnly = errors.New("store is read-only")
+
+type Store struct {
+	mutex    sync.RWMutex
+	path     string
+	data     data
+	closed   bool
+	readOnly bool
+
+	historyMutex      sync.RWMutex
+	historyWriteMutex sync.Mutex
+	history           history
+	historyFlush      *time.Timer
+	historyClosed     bool
+}
+
+type data struct {
+	Subscriptions        map[string]*Subscription       `json:"subscriptions"`
+	Alerts               map[string]time.Time           `json:"alerts"`
+	Monitor              *MonitorState                  `json:"monitor,omitempty"`
+	YandexJobs           map[string]*YandexJob          `json:"yandexJobs"`
+	YandexReconcileStart map[int]time.Time              `json:"yandexReconcileStart"`
+	YandexPollWatermarks map[int]time.Time              `json:"yandexPollWatermarks"`
+	Templates            map[string]*templates.Template `json:"templates"`
+}
+
+func Open(path string) (*Store, error) {
+	store, err := load(path, false)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := store.openHistory(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// OpenReadOnly loads the store without ever writing it, e.g. for the export
+// command while the service owns the file. Changes fail.
+func OpenReadOnly(path string) (*Store, error) {
+	store, err := load(path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.openHistory(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// load reads the store file. A missing file leaves an empty store together
+// with an error matching os.ErrNotExist.
+func load(path string, readOnly bool) (*Store, error) {
+	store := &Store{
+		path:     path,
+		readOnly: readOnly,
+		data: data{
+			Subscriptions:        make(map[string]*Subscription),
+			Alerts:               make(map[string]time.Time),
+			YandexJobs:           make(map[string]*YandexJob),
+			YandexReconcileStart: make(map[int]time.Time),
+			YandexPollWatermarks: make(map[int]time.Time),
+			Templates:            make(map[string]*templates.Template),
+		},
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return store, fmt.Errorf("failed to read store file: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &store.data); err != nil {
+		return nil, fmt.Errorf("failed to parse store file: %w", err)
+	}
+
+	if store.data.Subscriptions == nil {
+		store.data.Subscriptions = make(map[string]*Subscription)
+	}
+	if store.data.Alerts == nil {
+		store.data.Alerts = make(map[string]time.Time)
+	}
+	if store.data.YandexJobs == nil {
+		store.data.YandexJobs = make(map[string]*YandexJob)
+	}
+	if store.data.YandexReconcileStart == nil {
+		store.data.YandexReconcileStart = make(map[int]time.Time)
+	}
+	if store.data.YandexPollWatermarks == nil {
+		store.data.YandexPollWatermarks = make(map[int]time.Time)
+	}
+	if store.data.Templates == nil {
+		store.data.Templates = make(map[string]*templates.Template)
+	}
+
+	return store, nil
+}
+
+// Close writes the store and the history one last time. Changes made after
+// that fail.
+func (store *Store) Close() error {
+	if store.readOnly {
+		return nil
+	}
+
+	historyErr := store.closeHistory()
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.closed {
+		return historyErr
+	}
+
+	err := store.save()
+	store.closed = true
+
+	return errors.Join(historyErr, err)
+}
+
+// save must be called with the write lock held.
+func (store *Store) save() error {
+	if store.readOnly {
+		return errReadOnly
+	}
+	if store.closed {
+		return fmt.Errorf("store is closed")
+	}
+
+	content, err := json.MarshalIndent(store.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	tmpPath := store.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write store file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, store.path); err != nil {
+		return fmt.Errorf("failed to replace store file: %w", err)
+	}
+
+	return nil
+}
+
+// CheckWritable makes sure files can still be written next to the store file.
+func (store *Store) CheckWritable() error {
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	probe, err := os.CreateTemp(filepath.Dir(store.path), ".probe-*")
+	if err != nil {
+		return fmt.Errorf("failed to write to store directory: %w", err)
+	}
+
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"maps"
+	"marketplace-notifications/internal/config"
+	"sort"
+	"time"
+)
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionPending SubscriptionStatus = "pending"
+	SubscriptionActive  SubscriptionStatus = "active"
+	SubscriptionRemoved SubscriptionStatus = "removed"
+)
+
+type SubscriptionSource string
+
+const (
+	SourceConfig SubscriptionSource = "config"
+	SourceBot    SubscriptionSource = "bot"
+)
+
+type Subscription struct {
+	config.ChatTarget
+	Title       string             `json:"title,omitempty"`
+	Status      SubscriptionStatus `json:"status"`
+	Source      SubscriptionSource `json:"source"`
+	RequestedBy string             `json:"requestedBy,omitempty"`
+	MigratedTo  string             `json:"migratedTo,omitempty"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+// SeedSubscriptions adds statically configured chats the store doesn't know
+// yet, refreshes topic routes of the ones it already got from config and
+// forgets the ones no longer configured. Chats removed through the bot stay
+// removed while they are configured.
+func (store *Store) SeedSubscriptions(chats []config.ChatTarget) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	configured := make(map[string]bool)
+	for _, chat := range chats {
+		configured[chat.ChatId] = true
+	}
+
+	for chatId, subscription := range store.data.Subscriptions {
+		if subscription.Source == SourceConfig && !configured[chatId] {
+			delete(store.data.Subscriptions, chatId)
+		}
+	}
+
+	for _, chat := range chats {
+		subscription, ok := store.data.Subscriptions[chat.ChatId]
+		if !ok {
+			store.data.Subscriptions[chat.ChatId] = &Subscription{
+				ChatTarget: chat,
+				Status:     SubscriptionActive,
+				Source:     SourceConfig,
+				UpdatedAt:  time.Now(),
+			}
+			continue
+		}
+
+		if subscription.Source != SourceConfig {
+			continue
+		}
+
+		subscription.Forum = chat.Forum
+		if subscription.Threads == nil {
+			subscription.Threads = make(map[string]int)
+		}
+		for topic, threadId := range chat.Threads {
+			subscription.Threads[topic] = threadId
+		}
+	}
+
+	return store.save()
+}
+
+func (store *Store) ActiveSubscriptions() []config.ChatTarget {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var chats []config.ChatTarget
+	for _, subscription := range store.data.Subscriptions {
+		if subscription.Status == SubscriptionActive {
+			chats = append(chats, subscription.ChatTarget)
+		}
+	}
+
+	sort.Slice(chats, func(i, j int) bool {
+		return chats[i].ChatId < chats[j].ChatId
+	})
+
+	return chats
+}
+
+func (store *Store) Subscription(chatId string) (Subscription, bool) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	subscription, ok := store.data.Subscriptions[chatId]
+	if !ok {
+		return Subscription{}, false
+	}
+
+	return *subscription, true
+}
+
+func (store *Store) SetSubscriptionStatus(chatId, title string, status SubscriptionStatus, requestedBy string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	subscription, ok := store.data.Subscriptions[chatId]
+	if !ok {
+		subscription = &Subscription{
+			ChatTarget: config.ChatTarget{ChatId: chatId, Threads: make(map[string]int)},
+			Source:     SourceBot,
+		}
+		store.data.Subscriptions[chatId] = subscription
+	}
+
+	if title != "" {
+		subscription.Title = title
+	}
+	if requestedBy != "" {
+		subscription.RequestedBy = requestedBy
+	}
+	subscription.Status = status
+	subscription.UpdatedAt = time.Now()
+
+	return store.save()
+}
+
+func (store *Store) SetSubscriptionThread(chatId, topic string, threadId int) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	subscription, ok := store.data.Subscriptions[chatId]
+	if !ok {
+		return nil
+	}
+
+	if subscription.Threads == nil {
+		subscription.Threads = make(map[string]int)
+	}
+	subscription.Threads[topic] = threadId
+	subscription.UpdatedAt = time.Now()
+
+	return store.save()
+}
+
+// MigrateSubscription moves a subscription to the new id Telegram assigns to
+// a group upgraded to a supergroup. The new id isn't configured, so it is
+// kept as added through the bot, while the old one stays removed, also when
+// it is seeded again from config.
+func (store *Store) MigrateSubscription(oldChatId, newChatId string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	subscription, ok := store.data.Subscriptions[oldChatId]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+
+	migrated := *subscription
+	migrated.ChatId = newChatId
+	migrated.Threads = maps.Clone(subscription.Threads)
+	migrated.Source = SourceBot
+	migrated.UpdatedAt = now
+	store.data.Subscriptions[newChatId] = &migrated
+
+	subscription.Status = SubscriptionRemoved
+	subscription.MigratedTo = newChatId
+	subscription.UpdatedAt = now
+
+	return store.save()
+}
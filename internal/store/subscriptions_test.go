@@ -0,0 +1,86 @@
+package store
+
+import (
+	"marketplace-notifications/internal/config"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateSubscriptionReseeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		source SubscriptionSource
+	}{
+		{name: "configured chat", source: SourceConfig},
+		{name: "chat added through the bot", source: SourceBot},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := openTestStore(t)
+
+			chats := []config.ChatTarget{{ChatId: "-100"}}
+			if test.source == SourceConfig {
+				if err := store.SeedSubscriptions(chats); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if err := store.SetSubscriptionStatus("-100", "Group", SubscriptionActive, "admin"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := store.MigrateSubscription("-100", "-1001"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// A restart or a config reload seeds the old id again.
+			if err := store.SeedSubscriptions(chats); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			active := store.ActiveSubscriptions()
+			if len(active) != 1 || active[0].ChatId != "-1001" {
+				t.Fatalf("got active chats %+v, want only the migrated one", active)
+			}
+
+			old, ok := store.Subscription("-100")
+			if !ok || old.Status != SubscriptionRemoved || old.MigratedTo != "-1001" {
+				t.Fatalf("got old subscription %+v, want it removed and migrated", old)
+			}
+		})
+	}
+}
+
+func TestMigrateSubscriptionKeepsThreads(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.SeedSubscriptions([]config.ChatTarget{{ChatId: "-100", Forum: true, Threads: map[string]int{"wb": 2}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.MigrateSubscription("-100", "-1001"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.SetSubscriptionThread("-1001", "yandex", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	migrated, _ := store.Subscription("-1001")
+	if want := map[string]int{"wb": 2, "yandex": 3}; !migrated.Forum || !reflect.DeepEqual(migrated.Threads, want) {
+		t.Fatalf("got %+v, want a forum with threads %v", migrated.ChatTarget, want)
+	}
+
+	old, _ := store.Subscription("-100")
+	if want := map[string]int{"wb": 2}; !reflect.DeepEqual(old.Threads, want) {
+		t.Fatalf("threads of the old chat changed to %v", old.Threads)
+	}
+}
+
+func TestMigrateUnknownSubscription(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.MigrateSubscription("-100", "-1001"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := store.Subscription("-1001"); ok {
+		t.Fatal("unknown chats must not be migrated")
+	}
+}
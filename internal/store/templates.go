@@ -0,0 +1,47 @@
+package store
+
+import (
+	"marketplace-notifications/internal/templates"
+	"sort"
+)
+
+// Templates lists the reply templates added through the API by id.
+func (store *Store) Templates() []templates.Template {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	list := make([]templates.Template, 0, len(store.data.Templates))
+	for _, template := range store.data.Templates {
+		list = append(list, *template)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+
+	return list
+}
+
+func (store *Store) SaveTemplate(template templates.Template) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	template.Source = ""
+	store.data.Templates[template.Id] = &template
+
+	return store.save()
+}
+
+// DeleteTemplate removes a template, reporting false if there was none.
+func (store *Store) DeleteTemplate(id string) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.data.Templates[id]; !ok {
+		return false, nil
+	}
+
+	delete(store.data.Templates, id)
+
+	return true, store.save()
+}
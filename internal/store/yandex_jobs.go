@@ -0,0 +1,208 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobPending    JobStatus = "pending"
+	JobDone       JobStatus = "done"
+	JobFailed     JobStatus = "failed"
+	JobSummarized JobStatus = "summarized"
+	JobDiscarded  JobStatus = "discarded"
+)
+
+// finishedJobRetention is how long finished jobs are kept to deduplicate
+// repeated notifications about the same feedback.
+const finishedJobRetention = 7 * 24 * time.Hour
+
+// YandexJob is a queued Yandex feedback notification.
+type YandexJob struct {
+	Id            string    `json:"id"`
+	BusinessId    int       `json:"businessId"`
+	FeedbackId    int       `json:"feedbackId"`
+	CorrelationId string    `json:"correlationId"`
+	Status        JobStatus `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError,omitempty"`
+	ReceivedAt    time.Time `json:"receivedAt"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	FinishedAt    time.Time `json:"finishedAt,omitzero"`
+}
+
+func yandexJobId(businessId, feedbackId int) string {
+	return fmt.Sprintf("%d/%d", businessId, feedbackId)
+}
+
+// EnqueueYandexJob queues a feedback unless it was queued before, which is
+// reported by returning false.
+func (store *Store) EnqueueYandexJob(businessId, feedbackId int, correlationId string) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+
+	for id, job := range store.data.YandexJobs {
+		if job.Status != JobPending && now.Sub(job.FinishedAt) > finishedJobRetention {
+			delete(store.data.YandexJobs, id)
+		}
+	}
+
+	id := yandexJobId(businessId, feedbackId)
+	if _, ok := store.data.YandexJobs[id]; ok {
+		return false, nil
+	}
+
+	store.data.YandexJobs[id] = &YandexJob{
+		Id:            id,
+		BusinessId:    businessId,
+		FeedbackId:    feedbackId,
+		CorrelationId: correlationId,
+		Status:        JobPending,
+		ReceivedAt:    now,
+		NextAttemptAt: now,
+	}
+
+	if err := store.save(); err != nil {
+		delete(store.data.YandexJobs, id)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DueYandexJobs returns pending jobs whose next attempt is due, oldest first.
+func (store *Store) DueYandexJobs(now time.Time) []YandexJob {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var jobs []YandexJob
+	for _, job := range store.data.YandexJobs {
+		if job.Status == JobPending && !job.NextAttemptAt.After(now) {
+			jobs = append(jobs, *job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ReceivedAt.Before(jobs[j].ReceivedAt)
+	})
+
+	return jobs
+}
+
+func (store *Store) CompleteYandexJob(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	job, ok := store.data.YandexJobs[id]
+	if !ok {
+		return fmt.Errorf("unknown Yandex job %s", id)
+	}
+
+	job.Attempts++
+	job.Status = JobDone
+	job.LastError = ""
+	job.FinishedAt = time.Now()
+
+	return store.save()
+}
+
+// FailYandexJob records a failed attempt. The job is retried at nextAttemptAt,
+// or marked failed for good when nextAttemptAt is zero.
+func (store *Store) FailYandexJob(id string, err error, nextAttemptAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	job, ok := store.data.YandexJobs[id]
+	if !ok {
+		return fmt.Errorf("unknown Yandex job %s", id)
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+
+	if nextAttemptAt.IsZero() {
+		job.Status = JobFailed
+		job.FinishedAt = time.Now()
+	} else {
+		job.NextAttemptAt = nextAttemptAt
+	}
+
+	return store.save()
+}
+
+// ResolveYandexJobs finishes pending jobs without processing them.
+func (store *Store) ResolveYandexJobs(ids []string, status JobStatus) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+
+	for _, id := range ids {
+		if job, ok := store.data.YandexJobs[id]; ok && job.Status == JobPending {
+			job.Status = status
+			job.FinishedAt = now
+		}
+	}
+
+	return store.save()
+}
+
+// YandexJobCounts counts the queued jobs by status.
+func (store *Store) YandexJobCounts() map[JobStatus]int {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	counts := map[JobStatus]int{JobPending: 0, JobDone: 0, JobFailed: 0, JobSummarized: 0, JobDiscarded: 0}
+	for _, job := range store.data.YandexJobs {
+		counts[job.Status]++
+	}
+
+	return counts
+}
+
+// YandexReconcileStart returns when reconciliation first ran for the business,
+// recording now on the first call, so that feedbacks from before are never
+// reported as missed.
+func (store *Store) YandexReconcileStart(businessId int) (time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if start, ok := store.data.YandexReconcileStart[businessId]; ok {
+		return start, nil
+	}
+
+	start := time.Now()
+	store.data.YandexReconcileStart[businessId] = start
+
+	return start, store.save()
+}
+
+// YandexPollWatermark returns the creation time polling of the business has
+// reached, starting from now on the first call.
+func (store *Store) YandexPollWatermark(businessId int) (time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if watermark, ok := store.data.YandexPollWatermarks[businessId]; ok {
+		return watermark, nil
+	}
+
+	watermark := time.Now()
+	store.data.YandexPollWatermarks[businessId] = watermark
+
+	return watermark, store.save()
+}
+
+func (store *Store) SetYandexPollWatermark(businessId int, watermark time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.data.YandexPollWatermarks[businessId] = watermark
+
+	return store.save()
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestEnqueueYandexJob(t *testing.T) {
+	store := openTestStore(t)
+
+	steps := []struct {
+		businessId   int
+		feedbackId   int
+		wantEnqueued bool
+	}{
+		{1, 100, true},
+		{1, 100, false},
+		{1, 101, true},
+		{2, 100, true},
+	}
+
+	for i, step := range steps {
+		enqueued, err := store.EnqueueYandexJob(step.businessId, step.feedbackId, "")
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i+1, err)
+		}
+		if enqueued != step.wantEnqueued {
+			t.Fatalf("step %d: got enqueued %v, want %v", i+1, enqueued, step.wantEnqueued)
+		}
+	}
+
+	if got := store.YandexJobCounts()[JobPending]; got != 3 {
+		t.Fatalf("got %d pending jobs, want 3", got)
+	}
+}
+
+func TestEnqueueYandexJobDeduplicatesFinishedJobs(t *testing.T) {
+	store := openTestStore(t)
+
+	if _, err := store.EnqueueYandexJob(1, 100, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.CompleteYandexJob(yandexJobId(1, 100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	enqueued, err := store.EnqueueYandexJob(1, 100, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if enqueued {
+		t.Fatal("a finished job must not be queued again")
+	}
+}
+
+func TestEnqueueYandexJobRollsBackWhenNotSaved(t *testing.T) {
+	store := openTestStore(t)
+
+	// A file where the store directory should be makes every save fail.
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	store.path = filepath.Join(blocker, "store.json")
+
+	if _, err := store.EnqueueYandexJob(1, 100, ""); err == nil {
+		t.Fatal("expected the save to fail")
+	}
+	if got := store.YandexJobCounts()[JobPending]; got != 0 {
+		t.Fatalf("got %d pending jobs after a failed save, want 0", got)
+	}
+}
+
+func TestFailYandexJob(t *testing.T) {
+	store := openTestStore(t)
+	id := yandexJobId(1, 100)
+
+	if _, err := store.EnqueueYandexJob(1, 100, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+
+	tests := []struct {
+		name          string
+		nextAttemptAt time.Time
+		dueAt         time.Time
+		wantDue       int
+		wantStatus    JobStatus
+		wantAttempts  int
+	}{
+		{name: "not due before the retry", nextAttemptAt: retryAt, dueAt: now, wantDue: 0, wantStatus: JobPending, wantAttempts: 1},
+		{name: "due at the retry", nextAttemptAt: retryAt, dueAt: retryAt, wantDue: 1, wantStatus: JobPending, wantAttempts: 2},
+		{name: "failed for good", dueAt: retryAt, wantDue: 0, wantStatus: JobFailed, wantAttempts: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := store.FailYandexJob(id, errors.New("unavailable"), test.nextAttemptAt); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if due := store.DueYandexJobs(test.dueAt); len(due) != test.wantDue {
+				t.Fatalf("got %d due jobs, want %d", len(due), test.wantDue)
+			}
+
+			job := store.data.YandexJobs[id]
+			if job.Status != test.wantStatus || job.Attempts != test.wantAttempts || job.LastError != "unavailable" {
+				t.Fatalf("got job %+v, want status %s after %d attempts", job, test.wantStatus, test.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDueYandexJobsOldestFirst(t *testing.T) {
+	store := openTestStore(t)
+
+	for _, feedbackId := range []int{3, 1, 2} {
+		if _, err := store.EnqueueYandexJob(1, feedbackId, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	due := store.DueYandexJobs(time.Now())
+	if len(due) != 3 {
+		t.Fatalf("got %d due jobs, want 3", len(due))
+	}
+	for i, want := range []int{3, 1, 2} {
+		if due[i].FeedbackId != want {
+			t.Fatalf("job %d is feedback %d, want %d", i, due[i].FeedbackId, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tagging
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Rule tags texts containing any of the keywords, case-insensitively, or
+// matching any of the patterns.
+type Rule struct {
+	Tag      string   `yaml:"tag"`
+	Keywords []string `yaml:"keywords"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// tagPattern keeps tags usable as Telegram hashtags.
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+
+type Tagger struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	tag      string
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+func New(rules []Rule) (*Tagger, error) {
+	tagger := &Tagger{}
+
+	for _, rule := range rules {
+		if !tagPattern.MatchString(rule.Tag) {
+			return nil, fmt.Errorf("tag %q must be letters, digits and underscores", rule.Tag)
+		}
+		if len(rule.Keywords) == 0 && len(rule.Patterns) == 0 {
+			return nil, fmt.Errorf("tag %s has no keywords or patterns", rule.Tag)
+		}
+
+		compiled := compiledRule{tag: strings.ToLower(rule.Tag)}
+
+		for _, keyword := range rule.Keywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				compiled.keywords = append(compiled.keywords, keyword)
+			}
+		}
+
+		for _, pattern := range rule.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of tag %s: %w", rule.Tag, err)
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+
+		tagger.rules = append(tagger.rules, compiled)
+	}
+
+	return tagger, nil
+}
+
+// Tags lists the tags of the texts in the order of the rules.
+func (tagger *Tagger) Tags(texts ...string) []string {
+	var tags []string
+
+	for _, rule := range tagger.rules {
+		if !slices.Contains(tags, rule.tag) && rule.matches(texts) {
+			tags = append(tags, rule.tag)
+		}
+	}
+
+	return tags
+}
+
+func (rule compiledRule) matches(texts []string) bool {
+	for _, text := range texts {
+		lowered := strings.ToLower(text)
+
+		for _, keyword := range rule.keywords {
+			if strings.Contains(lowered, keyword) {
+				return true
+			}
+		}
+
+		for _, pattern := range rule.patterns {
+			if pattern.MatchString(text) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ParseRules parses entries of the form "tag:keyword|keyword|...".
+func ParseRules(entries []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(entries))
+
+	for _, entry := range entries {
+		tag, keywords, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid tag rule %q, expected tag:keyword|keyword", entry)
+		}
+
+		rules = append(rules, Rule{
+			Tag:      strings.TrimSpace(tag),
+			Keywords: strings.Split(keywords, "|"),
+		})
+	}
+
+	return rules, nil
+}
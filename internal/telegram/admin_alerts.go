@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"marketplace-notifications/internal/utils/format"
+	"strings"
+	"time"
+)
+
+var scopeNames = map[string]string{
+	wb.FeedbacksAndQuestionsScope: "Вопросы и отзывы",
+	wb.WriteScope:                 "Запись (токен только для чтения, а она нужна для ответов из Telegram)",
+}
+
+func (notifier *TelegramNotifier) SendWBTokenExpiryAlert(account string, info wb.TokenInfo, daysLeft int) error {
+	var message strings.Builder
+
+	if daysLeft <= 0 {
+		message.WriteString("⛔ *Токен WB истёк* ⛔\n\n")
+	} else {
+		message.WriteString("⚠️ *Токен WB скоро истечёт* ⚠️\n\n")
+	}
+
+	message.WriteString(formatAccount("WB", account))
+	message.WriteString(fmt.Sprintf("⌛  *Действует до:* %s\n", format.EscapeMarkdown(info.ExpiresAt.Format(time.DateTime))))
+	if daysLeft > 0 {
+		message.WriteString(fmt.Sprintf("🗓️  *Осталось дней:* %d\n", daysLeft))
+	}
+	message.WriteString("\n🔑 Выпустите новый токен в личном кабинете продавца\\.\n")
+
+	return notifier.sendNotificationToAdmins(message.String())
+}
+
+func (notifier *TelegramNotifier) SendWBTokenScopeAlert(account string, missingScopes []string) error {
+	var message strings.Builder
+
+	message.WriteString("⛔ *У токена WB нет нужного доступа* ⛔\n\n")
+	message.WriteString(formatAccount("WB", account))
+
+	var names []string
+	for _, scope := range missingScopes {
+		name, ok := scopeNames[scope]
+		if !ok {
+			name = scope
+		}
+		names = append(names, format.EscapeMarkdown(name))
+	}
+	message.WriteString(fmt.Sprintf("🚫  *Нет доступа к:* %s\n", strings.Join(names, ", ")))
+
+	return notifier.sendNotificationToAdmins(message.String())
+}
+
+func (notifier *TelegramNotifier) SendWBTokenErrorAlert(account string, err error) error {
+	var message strings.Builder
+
+	message.WriteString("⚠️ *Не удалось разобрать токен WB* ⚠️\n\n")
+	message.WriteString(formatAccount("WB", account))
+	message.WriteString(fmt.Sprintf("❗  *Ошибка:* %s\n", format.EscapeMarkdown(err.Error())))
+
+	return notifier.sendNotificationToAdmins(message.String())
+}
+
+func (notifier *TelegramNotifier) sendNotificationToAdmins(text string) error {
+	adminIds := notifier.currentConfig().AdminIds
+	if len(adminIds) == 0 {
+		return fmt.Errorf("no TELEGRAM_ADMIN_IDS configured")
+	}
+
+	var lastErr error
+	var successCount int
+
+	for _, adminId := range adminIds {
+		message := TelegramMessage{
+			ChatId:    adminId,
+			Text:      text,
+			ParseMode: "MarkdownV2",
+		}
+
+		if _, err := notifier.sendMessage(message); err != nil {
+			lastErr = err
+			slog.Error("Failed to send alert to admin", "chat", adminId, "error", err)
+		} else {
+			successCount++
+		}
+	}
+
+	if successCount == 0 && lastErr != nil {
+		return fmt.Errorf("Failed to send to all admins. Last error: %w", lastErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"marketplace-notifications/internal/metrics"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type TelegramMessage struct {
+	ChatId          string                `json:"chat_id"`
+	MessageThreadId int                   `json:"message_thread_id,omitempty"`
+	Text            string                `json:"text"`
+	ParseMode       string                `json:"parse_mode"`
+	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type TelegramEditMessage struct {
+	ChatId      string                `json:"chat_id"`
+	MessageId   int                   `json:"message_id"`
+	Text        string                `json:"text"`
+	ParseMode   string                `json:"parse_mode"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type TelegramResponse struct {
+	Ok          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+type TelegramSentMessage struct {
+	MessageId int `json:"message_id"`
+}
+
+func (notifier *TelegramNotifier) sendMessage(message TelegramMessage) (int, error) {
+	return notifier.send(message, false)
+}
+
+// sendUrgentMessage goes ahead of every call still waiting for the rate limit.
+func (notifier *TelegramNotifier) sendUrgentMessage(message TelegramMessage) (int, error) {
+	return notifier.send(message, true)
+}
+
+func (notifier *TelegramNotifier) send(message TelegramMessage, urgent bool) (int, error) {
+	var sentMessage TelegramSentMessage
+	if err := notifier.call("sendMessage", message, &sentMessage, urgent); err != nil {
+		metrics.TelegramSends.Inc(message.ChatId, "error")
+		return 0, err
+	}
+
+	metrics.TelegramSends.Inc(message.ChatId, "success")
+
+	return sentMessage.MessageId, nil
+}
+
+func (notifier *TelegramNotifier) editMessage(message TelegramEditMessage) error {
+	err := notifier.callMethod("editMessageText", message, nil)
+	if err != nil && strings.Contains(err.Error(), "message is not modified") {
+		return nil
+	}
+
+	return err
+}
+
+func (notifier *TelegramNotifier) callMethod(method string, payload, result any) error {
+	return notifier.call(method, payload, result, false)
+}
+
+func (notifier *TelegramNotifier) call(method string, payload, result any, urgent bool) error {
+	start := time.Now()
+	err := notifier.sendQueue.wait(context.Background(), urgent)
+	metrics.RateLimiterWait.Observe(time.Since(start).Seconds(), "Telegram")
+	if err != nil {
+		return fmt.Errorf("Telegram rate limiter error: %w", err)
+	}
+
+	return notifier.doRequest(context.Background(), notifier.httpClient, method, payload, result)
+}
+
+func (notifier *TelegramNotifier) doRequest(ctx context.Context, httpClient *http.Client, method string, payload, result any) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", notifier.methodURL(method), bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// The request URL carries the bot token, so only the cause is kept.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response body: %w", method, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Telegram returned status %d instead of 200: %s", resp.StatusCode, body)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	var response TelegramResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to parse %s result: %w", method, err)
+	}
+
+	return nil
+}
+
+func (notifier *TelegramNotifier) methodURL(method string) string {
+	return fmt.Sprintf("https://api.telegram.org/bot%s/%s", notifier.currentConfig().BotToken, method)
+}
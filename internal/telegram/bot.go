@@ -0,0 +1,124 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/store"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TelegramBot struct {
+	notifier   *TelegramNotifier
+	store      *store.Store
+	answerer   Answerer
+	httpClient *http.Client
+	offset     int
+	drafts     map[string]*replyDraft
+	posted     map[string]time.Time
+}
+
+func NewTelegramBot(config *config.TelegramConfig, notifier *TelegramNotifier, store *store.Store, answerer Answerer) *TelegramBot {
+	return &TelegramBot{
+		notifier: notifier,
+		store:    store,
+		answerer: answerer,
+		drafts:   make(map[string]*replyDraft),
+		posted:   make(map[string]time.Time),
+		httpClient: &http.Client{
+			Timeout: config.UpdatesTimeout + config.Timeout,
+		},
+	}
+}
+
+func (bot *TelegramBot) Run(ctx context.Context) {
+	slog.Info("Listening for Telegram bot updates")
+
+	for {
+		updates, err := bot.getUpdates(ctx)
+		if ctx.Err() != nil {
+			slog.Info("Telegram bot stopped")
+			return
+		}
+
+		if err != nil {
+			slog.Error("Failed to get Telegram updates", "error", err)
+
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				slog.Info("Telegram bot stopped")
+				return
+			}
+
+			continue
+		}
+
+		for _, update := range updates {
+			bot.offset = update.UpdateId + 1
+			bot.handleUpdate(update)
+		}
+	}
+}
+
+func (bot *TelegramBot) handleUpdate(update TelegramUpdate) {
+	switch {
+	case update.Message != nil:
+		bot.handleMessage(*update.Message)
+	case update.MyChatMember != nil:
+		bot.handleMyChatMember(*update.MyChatMember)
+	case update.CallbackQuery != nil:
+		bot.handleCallbackQuery(*update.CallbackQuery)
+	}
+}
+
+func (bot *TelegramBot) handleMessage(message TelegramIncomingMessage) {
+	if message.MigrateToChatId != 0 {
+		bot.migrateChat(message.Chat.ChatId(), strconv.FormatInt(message.MigrateToChatId, 10))
+		return
+	}
+
+	if message.From == nil || bot.handleReplyText(message) {
+		return
+	}
+
+	switch command(message.Text) {
+	case "/subscribe":
+		bot.requestSubscription(message.Chat, *message.From, message.MessageThreadId)
+	case "/unsubscribe":
+		bot.requestUnsubscription(message.Chat, *message.From, message.MessageThreadId)
+	}
+}
+
+func (bot *TelegramBot) isAdmin(user TelegramUser) bool {
+	return slices.Contains(bot.notifier.currentConfig().AdminIds, strconv.FormatInt(user.Id, 10))
+}
+
+func (bot *TelegramBot) reply(chatId string, threadId int, text string) {
+	message := TelegramMessage{
+		ChatId:          chatId,
+		MessageThreadId: threadId,
+		Text:            text,
+		ParseMode:       "MarkdownV2",
+	}
+
+	if _, err := bot.notifier.sendMessage(message); err != nil {
+		slog.Error("Failed to reply to chat", "chat", chatId, "error", err)
+	}
+}
+
+// command extracts a bot command from the message text, dropping arguments
+// and the "@BotName" suffix Telegram adds in groups.
+func command(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(fields[0], "@")
+	return name
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+)
+
+type TelegramForumTopic struct {
+	ChatId string `json:"chat_id"`
+	Name   string `json:"name"`
+}
+
+type TelegramCreatedForumTopic struct {
+	MessageThreadId int `json:"message_thread_id"`
+}
+
+var topicNames = map[string]string{
+	config.WBQuestionsTopic:     "WB: вопросы",
+	config.WBFeedbacksTopic:     "WB: отзывы",
+	config.YandexFeedbacksTopic: "Yandex: отзывы",
+}
+
+func reactionTopic(reactionType marketplaces.UserReactionType, serviceName string) string {
+	switch {
+	case serviceName == "WB" && reactionType == marketplaces.Question:
+		return config.WBQuestionsTopic
+	case serviceName == "WB":
+		return config.WBFeedbacksTopic
+	case serviceName == "Yandex" && reactionType == marketplaces.Feedback:
+		return config.YandexFeedbacksTopic
+	default:
+		return config.DefaultTopic
+	}
+}
+
+// threadId resolves the forum thread a message for the given topic goes to.
+// Zero means the chat's general thread.
+func (notifier *TelegramNotifier) threadId(ctx context.Context, chat config.ChatTarget, topic string) int {
+	if threadId, ok := chat.ThreadId(topic); ok {
+		return threadId
+	}
+
+	if chat.IsForum() && notifier.currentConfig().CreateForumTopics && topic != config.DefaultTopic {
+		threadId, err := notifier.forumTopic(ctx, chat.ChatId, topic)
+		if err == nil {
+			return threadId
+		}
+
+		logging.FromContext(ctx).Error("Failed to create forum topic", "topic", topic, "chat", chat.ChatId, "error", err)
+	}
+
+	threadId, _ := chat.ThreadId(config.DefaultTopic)
+	return threadId
+}
+
+func (notifier *TelegramNotifier) forumTopic(ctx context.Context, chatId, topic string) (int, error) {
+	notifier.topicsMutex.Lock()
+	defer notifier.topicsMutex.Unlock()
+
+	if subscription, ok := notifier.store.Subscription(chatId); ok {
+		if threadId, ok := subscription.ThreadId(topic); ok {
+			return threadId, nil
+		}
+	}
+
+	var createdTopic TelegramCreatedForumTopic
+	err := notifier.callMethod("createForumTopic", TelegramForumTopic{
+		ChatId: chatId,
+		Name:   topicNames[topic],
+	}, &createdTopic)
+	if err != nil {
+		return 0, err
+	}
+
+	if createdTopic.MessageThreadId == 0 {
+		return 0, fmt.Errorf("Telegram returned no thread id for topic %s", topic)
+	}
+
+	if err := notifier.store.SetSubscriptionThread(chatId, topic, createdTopic.MessageThreadId); err != nil {
+		logging.FromContext(ctx).Error("Failed to save forum topic", "topic", topic, "chat", chatId, "error", err)
+	}
+
+	logging.FromContext(ctx).Info("Created forum topic", "topic", topic, "chat", chatId)
+
+	return createdTopic.MessageThreadId, nil
+}
@@ -0,0 +1,36 @@
+package telegram
+
+import (
+	"context"
+	"marketplace-notifications/internal/health"
+	"time"
+)
+
+// connectionCheckTTL is how long the result of getMe is reused, so that
+// frequent probes don't all reach Telegram.
+const connectionCheckTTL = 15 * time.Second
+
+type botInfo struct {
+	Username string `json:"username"`
+}
+
+// CheckConnection calls getMe, bypassing the rate limiter so that probes
+// don't delay notifications. Probes in quick succession share the result.
+func (notifier *TelegramNotifier) CheckConnection(ctx context.Context) health.Component {
+	notifier.connectionMutex.Lock()
+	defer notifier.connectionMutex.Unlock()
+
+	if time.Since(notifier.connectionCheckedAt) < connectionCheckTTL {
+		return notifier.connectionCheck
+	}
+
+	var me TelegramUser
+	if err := notifier.doRequest(ctx, notifier.httpClient, "getMe", struct{}{}, &me); err != nil {
+		notifier.connectionCheck = health.Fail(err.Error(), nil)
+	} else {
+		notifier.connectionCheck = health.Ok("", botInfo{Username: me.Username})
+	}
+	notifier.connectionCheckedAt = time.Now()
+
+	return notifier.connectionCheck
+}
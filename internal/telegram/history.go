@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"context"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/store"
+	"strconv"
+	"time"
+)
+
+func (notifier *TelegramNotifier) recordReaction(ctx context.Context, reaction store.Reaction, notification string, deliveries []store.Delivery) {
+	if err := notifier.store.RecordReaction(reaction, notification, deliveries); err != nil {
+		logging.FromContext(ctx).Error("Failed to save reaction history", "error", err)
+	}
+}
+
+func newDelivery(chatId string, action store.DeliveryAction, messageId int, err error) store.Delivery {
+	delivery := store.Delivery{
+		ChatId:    chatId,
+		Action:    action,
+		MessageId: messageId,
+		At:        time.Now(),
+	}
+
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	return delivery
+}
+
+// sentMessages lists the notification messages that got through.
+func sentMessages(deliveries []store.Delivery) []store.Delivery {
+	var sent []store.Delivery
+	for _, delivery := range deliveries {
+		if delivery.Action == store.DeliverySend && delivery.Error == "" {
+			sent = append(sent, delivery)
+		}
+	}
+
+	return sent
+}
+
+func wbQuestionReaction(account string, question wb.Question) store.Reaction {
+	return store.Reaction{
+		Id:          store.ReactionId("WB", marketplaces.Question.String(), question.Id),
+		Marketplace: "WB",
+		Account:     account,
+		Type:        marketplaces.Question.String(),
+		ItemId:      question.Id,
+		Article:     strconv.Itoa(question.ProductDetails.Article),
+		ProductName: question.ProductDetails.Name,
+		Text:        question.Text,
+		CreatedAt:   question.CreatedDate,
+	}
+}
+
+func wbFeedbackReaction(account string, feedback wb.Feedback) store.Reaction {
+	return store.Reaction{
+		Id:          store.ReactionId("WB", marketplaces.Feedback.String(), feedback.Id),
+		Marketplace: "WB",
+		Account:     account,
+		Type:        marketplaces.Feedback.String(),
+		ItemId:      feedback.Id,
+		Rating:      feedback.NumberOfStars,
+		Article:     strconv.Itoa(feedback.ProductDetails.Article),
+		ProductName: feedback.ProductDetails.Name,
+		Text:        feedback.Text,
+		Pros:        feedback.Pros,
+		Cons:        feedback.Cons,
+		Customer:    feedback.UserName,
+		CreatedAt:   feedback.CreatedDate,
+	}
+}
+
+func yandexFeedbackReaction(account string, feedback yandex.Feedback) store.Reaction {
+	itemId := strconv.Itoa(feedback.Id)
+
+	var orderId string
+	if feedback.Identifiers.OrderId != 0 {
+		orderId = strconv.Itoa(feedback.Identifiers.OrderId)
+	}
+
+	return store.Reaction{
+		Id:          store.ReactionId("Yandex", marketplaces.Feedback.String(), itemId),
+		Marketplace: "Yandex",
+		Account:     account,
+		Type:        marketplaces.Feedback.String(),
+		ItemId:      itemId,
+		Rating:      feedback.Statistics.NumberOfStars,
+		OrderId:     orderId,
+		Customer:    feedback.Author,
+		Text:        feedback.Description.Text,
+		Pros:        feedback.Description.Pros,
+		Cons:        feedback.Description.Cons,
+		CreatedAt:   feedback.CreatedDate,
+	}
+}
@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces/wb"
+	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/store"
+	"marketplace-notifications/internal/utils/format"
+	"slices"
+	"strings"
+)
+
+type TelegramPinMessage struct {
+	ChatId              string `json:"chat_id"`
+	MessageId           int    `json:"message_id"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+}
+
+func (notifier *TelegramNotifier) negativeReviews() config.NegativeReviewConfig {
+	return notifier.currentConfig().NegativeReviews
+}
+
+// IsNegativeWBFeedback reports whether the feedback is alerted with priority.
+func (notifier *TelegramNotifier) IsNegativeWBFeedback(feedback wb.Feedback) bool {
+	return notifier.negativeReviews().Matches(feedback.NumberOfStars, true)
+}
+
+func (notifier *TelegramNotifier) isNegativeYandexFeedback(feedback yandex.Feedback) bool {
+	return notifier.negativeReviews().Matches(feedback.Statistics.NumberOfStars, feedback.Statistics.Recommended)
+}
+
+// escalate sends the first alert about a negative review to the escalation
+// chats.
+func (notifier *TelegramNotifier) escalate(ctx context.Context, text string, markup *InlineKeyboardMarkup) []store.Delivery {
+	var deliveries []store.Delivery
+
+	for _, chatId := range notifier.negativeReviews().EscalationChats {
+		message := TelegramMessage{
+			ChatId:      chatId,
+			Text:        text,
+			ParseMode:   "MarkdownV2",
+			ReplyMarkup: markup,
+		}
+
+		messageId, err := notifier.sendUrgentMessage(message)
+		deliveries = append(deliveries, newDelivery(chatId, store.DeliverySend, messageId, err))
+
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to send escalation", "chat", chatId, "error", err)
+		}
+	}
+
+	return deliveries
+}
+
+// pinMessages pins the alerts. The pins are recorded in the reaction
+// history, so that they are undone once answered, also after a restart.
+func (notifier *TelegramNotifier) pinMessages(ctx context.Context, sent []store.Delivery) []store.Delivery {
+	var deliveries []store.Delivery
+
+	for _, message := range sent {
+		err := notifier.call("pinChatMessage", TelegramPinMessage{ChatId: message.ChatId, MessageId: message.MessageId}, nil, true)
+		deliveries = append(deliveries, newDelivery(message.ChatId, store.DeliveryPin, message.MessageId, err))
+
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to pin message", "chat", message.ChatId, "messageId", message.MessageId, "error", err)
+		}
+	}
+
+	return deliveries
+}
+
+// unpinMessages unpins the messages the history shows as still pinned.
+func (notifier *TelegramNotifier) unpinMessages(ctx context.Context, history []store.Delivery) []store.Delivery {
+	var deliveries []store.Delivery
+
+	for _, message := range pinnedMessages(history) {
+		err := notifier.callMethod("unpinChatMessage", TelegramPinMessage{ChatId: message.ChatId, MessageId: message.MessageId}, nil)
+		deliveries = append(deliveries, newDelivery(message.ChatId, store.DeliveryUnpin, message.MessageId, err))
+
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to unpin message", "chat", message.ChatId, "messageId", message.MessageId, "error", err)
+		}
+	}
+
+	return deliveries
+}
+
+func pinnedMessages(history []store.Delivery) []store.Delivery {
+	var pinned []store.Delivery
+
+	for _, delivery := range history {
+		if delivery.Error != "" {
+			continue
+		}
+
+		isSame := func(pin store.Delivery) bool {
+			return pin.ChatId == delivery.ChatId && pin.MessageId == delivery.MessageId
+		}
+
+		switch delivery.Action {
+		case store.DeliveryPin:
+			if !slices.ContainsFunc(pinned, isSame) {
+				pinned = append(pinned, delivery)
+			}
+		case store.DeliveryUnpin:
+			pinned = slices.DeleteFunc(pinned, isSame)
+		}
+	}
+
+	return pinned
+}
+
+func (notifier *TelegramNotifier) formatNegativeReviewNotificationMessage(userReaction MardownFormatter, serviceName, account string) string {
+	var message strings.Builder
+
+	message.WriteString(fmt.Sprintf("🚨 *Негативный отзыв на %s\\!* 🚨\n\n", serviceName))
+
+	message.WriteString(formatAccount(serviceName, account))
+
+	message.WriteString(userReaction.FormatMarkdown())
+
+	return message.String()
+}
+
+func formatMentions(mentions []string) string {
+	if len(mentions) == 0 {
+		return ""
+	}
+
+	escaped := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		escaped = append(escaped, format.EscapeMarkdown(mention))
+	}
+
+	return fmt.Sprintf("\n📣  %s\n", strings.Join(escaped, " "))
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"marketplace-notifications/internal/store"
+	"reflect"
+	"testing"
+)
+
+func TestPinnedMessages(t *testing.T) {
+	pin := func(chatId string, messageId int) store.Delivery {
+		return store.Delivery{ChatId: chatId, Action: store.DeliveryPin, MessageId: messageId}
+	}
+	unpin := func(chatId string, messageId int) store.Delivery {
+		return store.Delivery{ChatId: chatId, Action: store.DeliveryUnpin, MessageId: messageId}
+	}
+	failed := func(delivery store.Delivery) store.Delivery {
+		delivery.Error = "not enough rights"
+		return delivery
+	}
+
+	tests := []struct {
+		name    string
+		history []store.Delivery
+		want    []store.Delivery
+	}{
+		{
+			name: "nothing pinned",
+			history: []store.Delivery{
+				{ChatId: "1", Action: store.DeliverySend, MessageId: 10},
+			},
+			want: nil,
+		},
+		{
+			name:    "pinned in two chats",
+			history: []store.Delivery{pin("1", 10), pin("2", 20)},
+			want:    []store.Delivery{pin("1", 10), pin("2", 20)},
+		},
+		{
+			name:    "two messages pinned in a chat",
+			history: []store.Delivery{pin("1", 10), pin("1", 11)},
+			want:    []store.Delivery{pin("1", 10), pin("1", 11)},
+		},
+		{
+			name:    "pinned twice",
+			history: []store.Delivery{pin("1", 10), pin("1", 10)},
+			want:    []store.Delivery{pin("1", 10)},
+		},
+		{
+			name:    "unpinned",
+			history: []store.Delivery{pin("1", 10), pin("2", 20), unpin("1", 10)},
+			want:    []store.Delivery{pin("2", 20)},
+		},
+		{
+			name:    "failed pins are left out",
+			history: []store.Delivery{failed(pin("1", 10))},
+			want:    nil,
+		},
+		{
+			name:    "a failed unpin keeps the pin",
+			history: []store.Delivery{pin("1", 10), failed(unpin("1", 10))},
+			want:    []store.Delivery{pin("1", 10)},
+		},
+		{
+			name:    "unpin of another message",
+			history: []store.Delivery{pin("1", 10), unpin("1", 11)},
+			want:    []store.Delivery{pin("1", 10)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := pinnedMessages(test.history); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSentMessages(t *testing.T) {
+	deliveries := []store.Delivery{
+		{ChatId: "1", Action: store.DeliverySend, MessageId: 10},
+		{ChatId: "2", Action: store.DeliverySend, Error: "chat not found"},
+		{ChatId: "1", Action: store.DeliveryPin, MessageId: 10},
+		{ChatId: "3", Action: store.DeliveryEdit, MessageId: 30},
+		{ChatId: "4", Action: store.DeliverySend, MessageId: 40},
+		{ChatId: "1", Action: store.DeliverySend, MessageId: 11},
+	}
+
+	want := []store.Delivery{deliveries[0], deliveries[4], deliveries[5]}
+	if got := sentMessages(deliveries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFormatMentions(t *testing.T) {
+	tests := []struct {
+		mentions []string
+		want     string
+	}{
+		{nil, ""},
+		{[]string{"@manager"}, "\n📣  @manager\n"},
+		{[]string{"@first_manager", "@second"}, "\n📣  @first\\_manager @second\n"},
+	}
+
+	for _, test := range tests {
+		if got := formatMentions(test.mentions); got != test.want {
+			t.Errorf("formatMentions(%v) = %q, want %q", test.mentions, got, test.want)
+		}
+	}
+}
@@ -1,20 +1,23 @@
 package telegram
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/health"
+	"marketplace-notifications/internal/logging"
 	"marketplace-notifications/internal/marketplaces"
 	"marketplace-notifications/internal/marketplaces/wb"
 	"marketplace-notifications/internal/marketplaces/yandex"
+	"marketplace-notifications/internal/metrics"
+	"marketplace-notifications/internal/store"
+	"marketplace-notifications/internal/tagging"
+	"marketplace-notifications/internal/utils/format"
 	"net/http"
+	"strconv"
 	"strings"
-
-	"golang.org/x/time/rate"
+	"sync"
+	"time"
 )
 
 type MardownFormatter interface {
@@ -22,111 +25,294 @@ type MardownFormatter interface {
 }
 
 type TelegramNotifier struct {
-	config          *config.TelegramConfig
-	httpClient      *http.Client
-	telegramLimiter *rate.Limiter
-}
-
-type TelegramMessage struct {
-	ChatId    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode"`
+	configMutex sync.RWMutex
+	config      *config.TelegramConfig
+	tagger      *tagging.Tagger
+	httpClient  *http.Client
+	sendQueue   *sendQueue
+	topicsMutex sync.Mutex
+	store       *store.Store
+
+	connectionMutex     sync.Mutex
+	connectionCheck     health.Component
+	connectionCheckedAt time.Time
 }
 
-func NewTelegramNotifier(config *config.TelegramConfig) *TelegramNotifier {
-	telegramLimiter := rate.NewLimiter(rate.Limit(config.RPS), config.RPS)
+func NewTelegramNotifier(config *config.TelegramConfig, store *store.Store) (*TelegramNotifier, error) {
+	tagger, err := tagging.New(config.Tagging.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile tag rules: %w", err)
+	}
 
-	return &TelegramNotifier{
+	notifier := &TelegramNotifier{
 		config: config,
+		tagger: tagger,
 		httpClient: &http.Client{
 			Timeout: config.Timeout,
 		},
-		telegramLimiter: telegramLimiter,
+		sendQueue: newSendQueue(config.RPS),
+		store:     store,
+	}
+	notifier.updateUnansweredGauge()
+
+	return notifier, nil
+}
+
+func (notifier *TelegramNotifier) UpdateConfig(config *config.TelegramConfig) error {
+	tagger, err := tagging.New(config.Tagging.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to compile tag rules: %w", err)
 	}
+
+	if err := notifier.store.SeedSubscriptions(config.Chats); err != nil {
+		return fmt.Errorf("failed to seed chat subscriptions: %w", err)
+	}
+
+	notifier.configMutex.Lock()
+	defer notifier.configMutex.Unlock()
+
+	notifier.config = config
+	notifier.tagger = tagger
+
+	return nil
+}
+
+func (notifier *TelegramNotifier) currentConfig() *config.TelegramConfig {
+	notifier.configMutex.RLock()
+	defer notifier.configMutex.RUnlock()
+
+	return notifier.config
+}
+
+func (notifier *TelegramNotifier) currentTagger() *tagging.Tagger {
+	notifier.configMutex.RLock()
+	defer notifier.configMutex.RUnlock()
+
+	return notifier.tagger
+}
+
+func (notifier *TelegramNotifier) SendSummaryNotificationToAllChats(ctx context.Context, serviceName, account string, reactionType marketplaces.UserReactionType, number int) error {
+	_, err := notifier.sendNotificationToAllChats(ctx, notifier.formatSummaryNotificationMessage(serviceName, account, reactionType, number), config.DefaultTopic, false, nil)
+	return err
+}
+
+// SendBufferedSummaryNotificationToAllChats reports items that arrived while
+// the monitor was stopped instead of sending each of them.
+func (notifier *TelegramNotifier) SendBufferedSummaryNotificationToAllChats(ctx context.Context, serviceName, account string, reactionType marketplaces.UserReactionType, number int, since time.Time) error {
+	_, err := notifier.sendNotificationToAllChats(ctx, notifier.formatBufferedSummaryNotificationMessage(serviceName, account, reactionType, number, since), config.DefaultTopic, false, nil)
+	return err
+}
+
+func (notifier *TelegramNotifier) SendWBQuestionNotificationToAllChats(ctx context.Context, account string, question wb.Question) error {
+	return notifier.sendUserReactionNotificationToAllChats(ctx, question, wbQuestionReaction(account, question), sentMessageKey{reactionType: marketplaces.Question, serviceName: "WB", account: account, id: question.Id})
 }
 
-func (notifier *TelegramNotifier) SendSummaryNotificationToAllChats(questionsNumber int, feedbacksNumber int) error {
-	return notifier.sendNotificationToAllChats(notifier.formatSummaryNotificationMessage(questionsNumber, feedbacksNumber))
+func (notifier *TelegramNotifier) SendWBFeedbackNotificationToAllChats(ctx context.Context, account string, feedback wb.Feedback) error {
+	reaction := wbFeedbackReaction(account, feedback)
+	reaction.Priority = notifier.IsNegativeWBFeedback(feedback)
+
+	return notifier.sendUserReactionNotificationToAllChats(ctx, feedback, reaction, sentMessageKey{reactionType: marketplaces.Feedback, serviceName: "WB", account: account, id: feedback.Id})
 }
 
-func (notifier *TelegramNotifier) SendWBQuestionNotificationToAllChats(question wb.Question) error {
-	return notifier.sendNotificationToAllChats(notifier.formatUserReactionNotificationMessage(question, marketplaces.Question, "WB"))
+func (notifier *TelegramNotifier) SendYandexFeedbackNotificationToAllChats(ctx context.Context, account string, feedback yandex.Feedback) error {
+	reaction := yandexFeedbackReaction(account, feedback)
+	reaction.Priority = notifier.isNegativeYandexFeedback(feedback)
+
+	return notifier.sendUserReactionNotificationToAllChats(ctx, feedback, reaction, sentMessageKey{reactionType: marketplaces.Feedback, serviceName: "Yandex", account: account, id: strconv.Itoa(feedback.Id)})
 }
 
-func (notifier *TelegramNotifier) SendWBFeedbackNotificationToAllChats(feedback wb.Feedback) error {
-	return notifier.sendNotificationToAllChats(notifier.formatUserReactionNotificationMessage(feedback, marketplaces.Feedback, "WB"))
+func (notifier *TelegramNotifier) MarkWBQuestionAnswered(ctx context.Context, account, questionId, answer string) error {
+	return notifier.markAnswered(ctx, sentMessageKey{reactionType: marketplaces.Question, serviceName: "WB", account: account, id: questionId}, answer)
 }
 
-func (notifier *TelegramNotifier) SendYandexFeedbackNotificationToAllChats(feedback yandex.Feedback) error {
-	return notifier.sendNotificationToAllChats(notifier.formatUserReactionNotificationMessage(feedback, marketplaces.Feedback, "Yandex"))
+func (notifier *TelegramNotifier) MarkWBFeedbackAnswered(ctx context.Context, account, feedbackId, answer string) error {
+	return notifier.markAnswered(ctx, sentMessageKey{reactionType: marketplaces.Feedback, serviceName: "WB", account: account, id: feedbackId}, answer)
+}
+
+// MarkReactionAnswered updates the notifications about a reaction answered
+// through the bot.
+func (notifier *TelegramNotifier) MarkReactionAnswered(ctx context.Context, reaction store.Reaction, answer string) error {
+	reactionType := marketplaces.Feedback
+	if reaction.Type == marketplaces.Question.String() {
+		reactionType = marketplaces.Question
+	}
+
+	return notifier.markAnswered(ctx, sentMessageKey{reactionType: reactionType, serviceName: reaction.Marketplace, account: reaction.Account, id: reaction.ItemId}, answer)
 }
 
-func (notifier *TelegramNotifier) sendNotificationToAllChats(text string) error {
+func (notifier *TelegramNotifier) sendUserReactionNotificationToAllChats(ctx context.Context, userReaction MardownFormatter, reaction store.Reaction, key sentMessageKey) error {
+	reaction.Tags = notifier.currentTagger().Tags(reaction.Text, reaction.Pros, reaction.Cons)
+
+	text := notifier.formatUserReactionNotificationMessage(userReaction, key.reactionType, key.serviceName, key.account)
+	if reaction.Priority {
+		text = notifier.formatNegativeReviewNotificationMessage(userReaction, key.serviceName, key.account)
+	}
+	text += formatTags(reaction.Tags)
+
+	// Yandex jobs are retried, so only the first alert about a negative review
+	// that got through is escalated, mentions and is pinned.
+	firstAlert := reaction.Priority && !notifier.store.IsNotified(reaction.Id)
+	if firstAlert {
+		text += formatMentions(notifier.negativeReviews().Mentions)
+	}
+
+	markup := notifier.replyMarkup(reaction)
+
+	deliveries, err := notifier.sendNotificationToAllChats(ctx, text, reactionTopic(key.reactionType, key.serviceName), reaction.Priority, markup)
+	deliveries = append(deliveries, notifier.sendToTagRoutes(ctx, text, reaction, deliveries, markup)...)
+	if firstAlert {
+		deliveries = append(deliveries, notifier.escalate(ctx, text, markup)...)
+	}
+
+	sent := sentMessages(deliveries)
+	if firstAlert && notifier.negativeReviews().Pin {
+		deliveries = append(deliveries, notifier.pinMessages(ctx, sent)...)
+	}
+	notifier.recordReaction(ctx, reaction, userReaction.FormatMarkdown(), deliveries)
+
+	if len(sent) > 0 {
+		metrics.ItemsNotified.Inc(key.serviceName, key.reactionType.String())
+	}
+	notifier.updateUnansweredGauge()
+
+	return err
+}
+
+// markAnswered edits the notification messages recorded in the history. The
+// reaction stays unanswered there, so that the next check tries again, until
+// an edit succeeds.
+func (notifier *TelegramNotifier) markAnswered(ctx context.Context, key sentMessageKey, answer string) error {
+	reactionId := store.ReactionId(key.serviceName, key.reactionType.String(), key.id)
+
+	reaction, ok := notifier.store.Reaction(reactionId)
+	if !ok {
+		return nil
+	}
+
+	text := notifier.formatAnsweredUserReactionNotificationMessage(notifier.store.ReactionNotification(reactionId), key.reactionType, key.serviceName, key.account, answer)
+	text += formatTags(reaction.Tags)
+
 	var lastErr error
 	var successCount int
+	var deliveries []store.Delivery
 
-	for _, chatId := range notifier.config.ChatIds {
-		message := TelegramMessage{
-			ChatId:    chatId,
+	for _, sent := range sentMessages(reaction.Deliveries) {
+		message := TelegramEditMessage{
+			ChatId:    sent.ChatId,
+			MessageId: sent.MessageId,
 			Text:      text,
 			ParseMode: "MarkdownV2",
 		}
 
-		if err := notifier.sendMessage(message); err != nil {
-			lastErr = err
-			log.Printf("[ERROR] Failed to send notification to chat: %s", chatId)
-		} else {
+		err := notifier.editMessage(message)
+		deliveries = append(deliveries, newDelivery(sent.ChatId, store.DeliveryEdit, sent.MessageId, err))
+
+		switch {
+		case err == nil:
 			successCount++
+		case isPermanentEditError(err):
+			logging.FromContext(ctx).Warn("Message can no longer be edited", "chat", sent.ChatId, "messageId", sent.MessageId, "error", err)
+		default:
+			lastErr = err
+			logging.FromContext(ctx).Error("Failed to edit message", "chat", sent.ChatId, "messageId", sent.MessageId, "error", err)
 		}
 	}
 
 	if successCount == 0 && lastErr != nil {
-		return fmt.Errorf("Failed to send to all chats. Last error: %w", lastErr)
+		return fmt.Errorf("Failed to edit message in all chats. Last error: %w", lastErr)
 	}
 
+	deliveries = append(deliveries, notifier.unpinMessages(ctx, reaction.Deliveries)...)
+
+	if err := notifier.store.MarkReactionAnswered(reactionId, answer, deliveries); err != nil {
+		logging.FromContext(ctx).Error("Failed to save answered reaction", "error", err)
+	}
+	notifier.updateUnansweredGauge()
+
 	return nil
 }
 
-func (notifier *TelegramNotifier) sendMessage(message TelegramMessage) error {
-	if err := notifier.telegramLimiter.Wait(context.Background()); err != nil {
-		return fmt.Errorf("Telegram rate limiter error: %w", err)
-	}
+// isPermanentEditError reports whether editing the message would fail again,
+// e.g. because it was deleted from the chat.
+func isPermanentEditError(err error) bool {
+	return strings.Contains(err.Error(), "message to edit not found") || strings.Contains(err.Error(), "message can't be edited")
+}
 
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
+// sendNotificationToAllChats sends the text to every subscribed chat, urgent
+// ones ahead of everything else waiting to be sent.
+func (notifier *TelegramNotifier) sendNotificationToAllChats(ctx context.Context, text, topic string, urgent bool, markup *InlineKeyboardMarkup) ([]store.Delivery, error) {
+	var lastErr error
+	var successCount int
+	var deliveries []store.Delivery
 
-	resp, err := notifier.httpClient.Post(notifier.sendMessageURL(), "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+	for _, chat := range notifier.store.ActiveSubscriptions() {
+		message := TelegramMessage{
+			ChatId:          chat.ChatId,
+			MessageThreadId: notifier.threadId(ctx, chat, topic),
+			Text:            text,
+			ParseMode:       "MarkdownV2",
+			ReplyMarkup:     markup,
+		}
+
+		messageId, err := notifier.send(message, urgent)
+		deliveries = append(deliveries, newDelivery(chat.ChatId, store.DeliverySend, messageId, err))
+
+		if err != nil {
+			lastErr = err
+			logging.FromContext(ctx).Error("Failed to send notification", "chat", chat.ChatId, "error", err)
+		} else {
+			successCount++
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Telegram returned status %d instead of 200: %s", resp.StatusCode, body)
+	if successCount == 0 && lastErr != nil {
+		return deliveries, fmt.Errorf("Failed to send to all chats. Last error: %w", lastErr)
 	}
 
-	return nil
+	return deliveries, nil
 }
 
-func (notifier *TelegramNotifier) formatSummaryNotificationMessage(questionsNumber, feedbacksNumber int) string {
+func (notifier *TelegramNotifier) formatSummaryNotificationMessage(serviceName, account string, reactionType marketplaces.UserReactionType, number int) string {
 	var message strings.Builder
 
 	message.WriteString(fmt.Sprintf("🔔 *Пользователи ждут вашего ответа\\!* 🔔\n\n"))
 
+	message.WriteString(formatAccount(serviceName, account))
+
 	message.WriteString(fmt.Sprintf("*🗓️ На данный момент у вас:*\n\n"))
 
-	message.WriteString(fmt.Sprintf("❔ Неотвеченных *вопросов*: %d\n", questionsNumber))
-	message.WriteString(fmt.Sprintf("💬 Неотвеченных *отзывов*: %d\n\n", feedbacksNumber))
+	if reactionType == marketplaces.Question {
+		message.WriteString(fmt.Sprintf("❔ Неотвеченных *вопросов*: %d\n\n", number))
+	} else {
+		message.WriteString(fmt.Sprintf("💬 Неотвеченных *отзывов*: %d\n\n", number))
+	}
+
+	message.WriteString(fmt.Sprintf("📃 Новые из них — в сообщениях ниже:\n"))
+
+	return message.String()
+}
+
+func (notifier *TelegramNotifier) formatBufferedSummaryNotificationMessage(serviceName, account string, reactionType marketplaces.UserReactionType, number int, since time.Time) string {
+	var message strings.Builder
+
+	message.WriteString(fmt.Sprintf("🔔 *Пока мониторинг был остановлен, пришли новые сообщения* 🔔\n\n"))
 
-	message.WriteString(fmt.Sprintf("📃 Полный список в сообщениях ниже:\n"))
+	message.WriteString(formatAccount(serviceName, account))
+
+	if reactionType == marketplaces.Question {
+		message.WriteString(fmt.Sprintf("❔ Новых *вопросов*: %d\n", number))
+	} else {
+		message.WriteString(fmt.Sprintf("💬 Новых *отзывов*: %d\n", number))
+	}
+
+	message.WriteString(fmt.Sprintf("⌚  *Первое получено:* %s\n\n", format.EscapeMarkdown(since.Format(time.DateTime))))
+
+	message.WriteString(fmt.Sprintf("📃 Их слишком много, чтобы присылать по одному, посмотрите их в кабинете %s\\.\n", serviceName))
 
 	return message.String()
 }
 
-func (notifier *TelegramNotifier) formatUserReactionNotificationMessage(userReaction MardownFormatter, reactionType marketplaces.UserReactionType, serviceName string) string {
+func (notifier *TelegramNotifier) formatUserReactionNotificationMessage(userReaction MardownFormatter, reactionType marketplaces.UserReactionType, serviceName, account string) string {
 	var message strings.Builder
 
 	if reactionType == marketplaces.Question {
@@ -135,11 +321,35 @@ func (notifier *TelegramNotifier) formatUserReactionNotificationMessage(userReac
 		message.WriteString(fmt.Sprintf("*💬 Неотвеченный отзыв на %s:*\n\n", serviceName))
 	}
 
+	message.WriteString(formatAccount(serviceName, account))
+
 	message.WriteString(userReaction.FormatMarkdown())
 
 	return message.String()
 }
 
-func (notifier *TelegramNotifier) sendMessageURL() string {
-	return fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", notifier.config.BotToken)
+// formatAnsweredUserReactionNotificationMessage shows the body of the
+// notification as it was sent, together with the answer.
+func (notifier *TelegramNotifier) formatAnsweredUserReactionNotificationMessage(notification string, reactionType marketplaces.UserReactionType, serviceName, account, answer string) string {
+	var message strings.Builder
+
+	if reactionType == marketplaces.Question {
+		message.WriteString(fmt.Sprintf("*✅ Отвеченный вопрос на %s:*\n\n", serviceName))
+	} else {
+		message.WriteString(fmt.Sprintf("*✅ Отвеченный отзыв на %s:*\n\n", serviceName))
+	}
+
+	message.WriteString(formatAccount(serviceName, account))
+
+	message.WriteString(notification)
+
+	if answer != "" {
+		message.WriteString(fmt.Sprintf("\n✅  *Ответ:* %s\n", format.EscapeMarkdown(answer)))
+	}
+
+	return message.String()
+}
+
+func formatAccount(serviceName, account string) string {
+	return fmt.Sprintf("🏪  *Кабинет %s:* %s\n\n", serviceName, format.EscapeMarkdown(account))
 }
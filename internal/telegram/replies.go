@@ -0,0 +1,473 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/store"
+	"marketplace-notifications/internal/templates"
+	"marketplace-notifications/internal/utils/format"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	replyAction    = "reply"
+	startDecision  = "start"
+	pickDecision   = "pick"
+	writeDecision  = "write"
+	postDecision   = "post"
+	cancelDecision = "cancel"
+)
+
+const (
+	// callbackDataLimit is the most Telegram accepts in a button.
+	callbackDataLimit = 64
+	// draftLifetime is how long an unfinished answer is kept.
+	draftLifetime = 24 * time.Hour
+	// maxTemplateButtons keeps the template list short enough to scan.
+	maxTemplateButtons = 8
+	maxReplyLength     = 3000
+	maxQuotedLength    = 500
+)
+
+// Answerer posts answers to the marketplaces.
+type Answerer interface {
+	AnswerReaction(ctx context.Context, reaction store.Reaction, text string) error
+}
+
+// replyDraft is an answer being prepared in a chat, shown in the preview
+// message. Text sent in reply to the prompt message replaces the answer.
+type replyDraft struct {
+	reaction  store.Reaction
+	text      string
+	chatId    string
+	threadId  int
+	previewId int
+	promptId  int
+	createdAt time.Time
+}
+
+type TelegramForceReply struct {
+	ForceReply            bool   `json:"force_reply"`
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+}
+
+type TelegramPromptMessage struct {
+	ChatId          string             `json:"chat_id"`
+	MessageThreadId int                `json:"message_thread_id,omitempty"`
+	Text            string             `json:"text"`
+	ParseMode       string             `json:"parse_mode"`
+	ReplyMarkup     TelegramForceReply `json:"reply_markup"`
+}
+
+// ReplyTemplates lists the configured templates followed by the ones added
+// through the API.
+func (notifier *TelegramNotifier) ReplyTemplates() []templates.Template {
+	return templates.Merge(notifier.currentConfig().ReplyTemplates, notifier.store.Templates())
+}
+
+// replyMarkup offers to answer the reaction from Telegram, which only admins
+// are allowed to do.
+// AnsweringEnabled reports whether admins can answer reactions from Telegram.
+func (notifier *TelegramNotifier) AnsweringEnabled() bool {
+	return len(notifier.currentConfig().AdminIds) > 0
+}
+
+func (notifier *TelegramNotifier) replyMarkup(reaction store.Reaction) *InlineKeyboardMarkup {
+	if !notifier.AnsweringEnabled() {
+		return nil
+	}
+
+	data := callbackData(replyAction, startDecision, reaction.Id)
+	if len(data) > callbackDataLimit {
+		return nil
+	}
+
+	return &InlineKeyboardMarkup{
+		InlineKeyboard: [][]InlineKeyboardButton{{
+			{Text: "✍️ Ответить", CallbackData: data},
+		}},
+	}
+}
+
+func isReplyCallback(data string) bool {
+	return strings.HasPrefix(data, replyAction+":")
+}
+
+func (bot *TelegramBot) handleReplyCallback(query TelegramCallbackQuery) {
+	if !bot.isAdmin(query.From) {
+		bot.answerCallbackQuery(query.Id, "Только администраторы могут отвечать")
+		return
+	}
+
+	parts := strings.SplitN(query.Data, ":", 3)
+	if len(parts) != 3 || query.Message == nil {
+		bot.answerCallbackQuery(query.Id, "")
+		return
+	}
+	decision, argument := parts[1], parts[2]
+
+	if decision == startDecision {
+		bot.startReply(query, argument)
+		return
+	}
+
+	draftId, templateId, _ := strings.Cut(argument, ":")
+
+	draft, ok := bot.drafts[draftId]
+	if !ok {
+		bot.answerCallbackQuery(query.Id, "Черновик ответа устарел, нажмите «Ответить» ещё раз")
+		return
+	}
+
+	switch decision {
+	case pickDecision:
+		bot.pickTemplate(query, draftId, draft, templateId)
+	case writeDecision:
+		bot.promptReplyText(query, draft)
+	case postDecision:
+		bot.postReply(query, draftId, draft)
+	case cancelDecision:
+		delete(bot.drafts, draftId)
+		bot.answerCallbackQuery(query.Id, "")
+		bot.editPreview(draft, "✖️ Ответ отменён\\.", nil)
+	default:
+		bot.answerCallbackQuery(query.Id, "")
+	}
+}
+
+func (bot *TelegramBot) startReply(query TelegramCallbackQuery, reactionId string) {
+	reaction, ok := bot.store.Reaction(reactionId)
+	if !ok {
+		bot.answerCallbackQuery(query.Id, "Отзыв не найден в истории")
+		return
+	}
+	if bot.isAnswered(reactionId) {
+		bot.answerCallbackQuery(query.Id, "На него уже ответили")
+		return
+	}
+
+	bot.pruneDrafts()
+
+	draftId, err := newDraftId()
+	if err != nil {
+		slog.Error("Failed to start answer", "error", err)
+		bot.answerCallbackQuery(query.Id, "Не удалось начать ответ")
+		return
+	}
+
+	draft := &replyDraft{
+		reaction:  reaction,
+		chatId:    query.Message.Chat.ChatId(),
+		threadId:  query.Message.MessageThreadId,
+		createdAt: time.Now(),
+	}
+
+	message := TelegramMessage{
+		ChatId:          draft.chatId,
+		MessageThreadId: draft.threadId,
+		Text:            formatReplyPreview(draft),
+		ParseMode:       "MarkdownV2",
+		ReplyMarkup:     bot.previewMarkup(draftId, draft),
+	}
+
+	messageId, err := bot.notifier.sendMessage(message)
+	if err != nil {
+		slog.Error("Failed to send answer preview", "chat", draft.chatId, "error", err)
+		bot.answerCallbackQuery(query.Id, "Не удалось начать ответ")
+		return
+	}
+
+	draft.previewId = messageId
+	bot.drafts[draftId] = draft
+	bot.answerCallbackQuery(query.Id, "")
+}
+
+func (bot *TelegramBot) pickTemplate(query TelegramCallbackQuery, draftId string, draft *replyDraft, templateId string) {
+	for _, template := range bot.matchingTemplates(draft.reaction) {
+		if template.Id != templateId {
+			continue
+		}
+
+		draft.text = template.Fill(templates.Values{
+			Product:  draft.reaction.ProductName,
+			Article:  draft.reaction.Article,
+			Customer: draft.reaction.Customer,
+			Stars:    draft.reaction.Rating,
+		})
+
+		bot.answerCallbackQuery(query.Id, "")
+		bot.editPreview(draft, formatReplyPreview(draft), bot.previewMarkup(draftId, draft))
+		return
+	}
+
+	bot.answerCallbackQuery(query.Id, "Шаблон больше не подходит")
+}
+
+// promptReplyText asks for the answer in a reply, the current text is shown
+// in a code block so that it can be copied and edited.
+func (bot *TelegramBot) promptReplyText(query TelegramCallbackQuery, draft *replyDraft) {
+	var text strings.Builder
+	text.WriteString("✏️ Пришлите текст ответа ответом на это сообщение\\.")
+	if draft.text != "" {
+		text.WriteString(" Текущий текст, его можно скопировать нажатием:\n\n")
+		text.WriteString(fmt.Sprintf("```\n%s\n```", escapeCode(draft.text)))
+	}
+
+	message := TelegramPromptMessage{
+		ChatId:          draft.chatId,
+		MessageThreadId: draft.threadId,
+		Text:            text.String(),
+		ParseMode:       "MarkdownV2",
+		ReplyMarkup:     TelegramForceReply{ForceReply: true, InputFieldPlaceholder: "Текст ответа"},
+	}
+
+	var sent TelegramSentMessage
+	if err := bot.notifier.callMethod("sendMessage", message, &sent); err != nil {
+		slog.Error("Failed to send answer prompt", "chat", draft.chatId, "error", err)
+		bot.answerCallbackQuery(query.Id, "Не удалось запросить текст")
+		return
+	}
+
+	draft.promptId = sent.MessageId
+	bot.answerCallbackQuery(query.Id, "")
+}
+
+// handleReplyText takes the answer sent in reply to a prompt, reporting
+// whether the message was one.
+func (bot *TelegramBot) handleReplyText(message TelegramIncomingMessage) bool {
+	if message.ReplyToMessage == nil {
+		return false
+	}
+
+	chatId := message.Chat.ChatId()
+
+	for draftId, draft := range bot.drafts {
+		if draft.chatId != chatId || draft.promptId == 0 || draft.promptId != message.ReplyToMessage.MessageId {
+			continue
+		}
+
+		if !bot.isAdmin(*message.From) {
+			return true
+		}
+
+		text := strings.TrimSpace(message.Text)
+		switch {
+		case text == "":
+			bot.reply(chatId, message.MessageThreadId, "ℹ️ Пришлите ответ текстом\\.")
+		case utf8.RuneCountInString(text) > maxReplyLength:
+			bot.reply(chatId, message.MessageThreadId, fmt.Sprintf("ℹ️ Ответ длиннее %d символов, сократите его\\.", maxReplyLength))
+		default:
+			draft.text = text
+			bot.editPreview(draft, formatReplyPreview(draft), bot.previewMarkup(draftId, draft))
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (bot *TelegramBot) postReply(query TelegramCallbackQuery, draftId string, draft *replyDraft) {
+	if draft.text == "" {
+		bot.answerCallbackQuery(query.Id, "Сначала выберите шаблон или пришлите текст")
+		return
+	}
+
+	if bot.isAnswered(draft.reaction.Id) {
+		delete(bot.drafts, draftId)
+		bot.answerCallbackQuery(query.Id, "На него уже ответили")
+		bot.editPreview(draft, "ℹ️ На него уже ответили, ответ не опубликован\\.", nil)
+		return
+	}
+
+	// The reaction is answered once, from this draft.
+	bot.closeOtherDrafts(draftId, draft.reaction.Id)
+
+	bot.answerCallbackQuery(query.Id, "⏳ Публикую ответ")
+
+	ctx, logger := logging.WithCorrelationId(context.Background())
+	logger = logger.With("action", "telegram answer", "reaction", draft.reaction.Id, "userId", query.From.Id)
+
+	if err := bot.answerer.AnswerReaction(ctx, draft.reaction, draft.text); err != nil {
+		logger.Warn("Audit", "result", err.Error())
+
+		// It may have been answered meanwhile, e.g. in the marketplace
+		// cabinet, which makes the post fail.
+		if reaction, ok := bot.store.Reaction(draft.reaction.Id); ok {
+			draft.reaction = reaction
+		}
+		if bot.isAnswered(draft.reaction.Id) {
+			delete(bot.drafts, draftId)
+			bot.editPreview(draft, "ℹ️ На него уже ответили, ответ не опубликован\\.", nil)
+			return
+		}
+
+		text := formatReplyPreview(draft) + fmt.Sprintf("\n❌ Не удалось опубликовать ответ: %s\n", format.EscapeMarkdown(err.Error()))
+		bot.editPreview(draft, text, bot.previewMarkup(draftId, draft))
+		return
+	}
+
+	logger.Info("Audit", "result", "done")
+	delete(bot.drafts, draftId)
+	bot.posted[draft.reaction.Id] = time.Now()
+
+	text := fmt.Sprintf("✅ *Ответ опубликован*, автор %s:\n\n%s\n", format.EscapeMarkdown(query.From.DisplayName()), format.EscapeMarkdown(draft.text))
+	bot.editPreview(draft, text, nil)
+}
+
+// isAnswered reports whether the reaction was answered. Answers posted from
+// here count right away, before the history is updated, which waits for the
+// notifications to be edited.
+func (bot *TelegramBot) isAnswered(reactionId string) bool {
+	if _, ok := bot.posted[reactionId]; ok {
+		return true
+	}
+
+	reaction, ok := bot.store.Reaction(reactionId)
+
+	return ok && reaction.Answered
+}
+
+// closeOtherDrafts closes the drafts for the reaction other than the one
+// being posted, which other admins may have started in other chats.
+func (bot *TelegramBot) closeOtherDrafts(draftId, reactionId string) {
+	for otherId, other := range bot.drafts {
+		if otherId == draftId || other.reaction.Id != reactionId {
+			continue
+		}
+
+		delete(bot.drafts, otherId)
+		bot.editPreview(other, "ℹ️ Ответ публикуют из другого черновика, этот закрыт\\.", nil)
+	}
+}
+
+func (bot *TelegramBot) matchingTemplates(reaction store.Reaction) []templates.Template {
+	var matching []templates.Template
+
+	for _, template := range bot.notifier.ReplyTemplates() {
+		if template.Matches(reaction.Type, reaction.Rating, reaction.Tags) {
+			matching = append(matching, template)
+		}
+	}
+
+	return matching
+}
+
+func (bot *TelegramBot) previewMarkup(draftId string, draft *replyDraft) *InlineKeyboardMarkup {
+	var rows [][]InlineKeyboardButton
+
+	for i, template := range bot.matchingTemplates(draft.reaction) {
+		if i == maxTemplateButtons {
+			break
+		}
+
+		rows = append(rows, []InlineKeyboardButton{
+			{Text: "📄 " + template.Name, CallbackData: callbackData(replyAction, pickDecision, draftId+":"+template.Id)},
+		})
+	}
+
+	actions := []InlineKeyboardButton{
+		{Text: "✏️ Свой текст", CallbackData: callbackData(replyAction, writeDecision, draftId)},
+	}
+	if draft.text != "" {
+		actions[0].Text = "✏️ Изменить"
+		actions = append(actions, InlineKeyboardButton{Text: "📤 Опубликовать", CallbackData: callbackData(replyAction, postDecision, draftId)})
+	}
+	actions = append(actions, InlineKeyboardButton{Text: "✖️ Отмена", CallbackData: callbackData(replyAction, cancelDecision, draftId)})
+
+	return &InlineKeyboardMarkup{InlineKeyboard: append(rows, actions)}
+}
+
+func (bot *TelegramBot) editPreview(draft *replyDraft, text string, markup *InlineKeyboardMarkup) {
+	message := TelegramEditMessage{
+		ChatId:      draft.chatId,
+		MessageId:   draft.previewId,
+		Text:        text,
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: markup,
+	}
+
+	if err := bot.notifier.editMessage(message); err != nil {
+		slog.Error("Failed to update answer preview", "chat", draft.chatId, "error", err)
+	}
+}
+
+func (bot *TelegramBot) pruneDrafts() {
+	for draftId, draft := range bot.drafts {
+		if time.Since(draft.createdAt) > draftLifetime {
+			delete(bot.drafts, draftId)
+		}
+	}
+
+	for reactionId, postedAt := range bot.posted {
+		if time.Since(postedAt) > draftLifetime {
+			delete(bot.posted, reactionId)
+		}
+	}
+}
+
+func formatReplyPreview(draft *replyDraft) string {
+	reaction := draft.reaction
+
+	var message strings.Builder
+
+	if reaction.Type == marketplaces.Question.String() {
+		message.WriteString(fmt.Sprintf("*✍️ Ответ на вопрос на %s:*\n\n", reaction.Marketplace))
+	} else {
+		message.WriteString(fmt.Sprintf("*✍️ Ответ на отзыв на %s:*\n\n", reaction.Marketplace))
+	}
+
+	message.WriteString(formatAccount(reaction.Marketplace, reaction.Account))
+
+	if reaction.ProductName != "" {
+		message.WriteString(fmt.Sprintf("📦  *Товар:* %s \\(%s\\)\n", format.EscapeMarkdown(reaction.ProductName), format.EscapeMarkdown(reaction.Article)))
+	}
+	if reaction.Rating > 0 {
+		message.WriteString(fmt.Sprintf("⭐  *Оценка:* %d\n", reaction.Rating))
+	}
+	if reaction.Customer != "" {
+		message.WriteString(fmt.Sprintf("👤  *Покупатель:* %s\n", format.EscapeMarkdown(reaction.Customer)))
+	}
+	for _, quoted := range []string{reaction.Text, reaction.Pros, reaction.Cons} {
+		if quoted != "" {
+			message.WriteString(fmt.Sprintf("💬  %s\n", format.EscapeMarkdown(truncate(quoted, maxQuotedLength))))
+		}
+	}
+
+	if draft.text == "" {
+		message.WriteString("\n📄 Выберите шаблон или пришлите свой текст\\.\n")
+	} else {
+		message.WriteString(fmt.Sprintf("\n📝  *Ответ:*\n%s\n", format.EscapeMarkdown(draft.text)))
+	}
+
+	return message.String()
+}
+
+func truncate(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	return string([]rune(text)[:length]) + "…"
+}
+
+// escapeCode escapes the text of a MarkdownV2 code block.
+func escapeCode(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(text)
+}
+
+func newDraftId() (string, error) {
+	bytes := make([]byte, 4)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate draft id: %w", err)
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"marketplace-notifications/internal/config"
+	"marketplace-notifications/internal/store"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTelegram answers every Bot API call successfully.
+type fakeTelegram struct{}
+
+func (fakeTelegram) RoundTrip(request *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"ok":true,"result":true}`)),
+		Request:    request,
+	}, nil
+}
+
+type fakeAnswerer struct {
+	store             *store.Store
+	err               error
+	answeredMeanwhile bool
+	posts             int
+}
+
+func (answerer *fakeAnswerer) AnswerReaction(ctx context.Context, reaction store.Reaction, text string) error {
+	answerer.posts++
+
+	if answerer.answeredMeanwhile {
+		if err := answerer.store.MarkReactionAnswered(reaction.Id, "", nil); err != nil {
+			return err
+		}
+	}
+
+	return answerer.err
+}
+
+func TestPostReply(t *testing.T) {
+	tests := []struct {
+		name              string
+		answeredBefore    bool
+		answerErr         error
+		answeredMeanwhile bool
+		wantPosts         int
+		wantDrafts        []string
+		wantAnswered      bool
+	}{
+		{name: "posted once", wantPosts: 1, wantDrafts: []string{}, wantAnswered: true},
+		{name: "already answered", answeredBefore: true, wantPosts: 0, wantDrafts: []string{}, wantAnswered: true},
+		{name: "failed post", answerErr: errors.New("WB is down"), wantPosts: 1, wantDrafts: []string{"first"}, wantAnswered: false},
+		{
+			name:              "answered while posting",
+			answerErr:         errors.New("feedback already answered"),
+			answeredMeanwhile: true,
+			wantPosts:         1,
+			wantDrafts:        []string{},
+			wantAnswered:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reactionStore, err := store.Open(filepath.Join(t.TempDir(), "store.json"))
+			if err != nil {
+				t.Fatalf("failed to open store: %v", err)
+			}
+			t.Cleanup(func() { reactionStore.Close() })
+
+			reaction := store.Reaction{Id: "wb:feedbacks:1", Marketplace: "WB", Type: "feedbacks", ItemId: "1", CreatedAt: time.Now()}
+			if err := reactionStore.RecordReaction(reaction, "", nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.answeredBefore {
+				if err := reactionStore.MarkReactionAnswered(reaction.Id, "", nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			answerer := &fakeAnswerer{store: reactionStore, err: test.answerErr, answeredMeanwhile: test.answeredMeanwhile}
+			bot := &TelegramBot{
+				notifier: &TelegramNotifier{
+					config:     &config.TelegramConfig{BotToken: "1:a", AdminIds: []string{"1"}},
+					httpClient: &http.Client{Transport: fakeTelegram{}},
+					sendQueue:  newSendQueue(1000),
+					store:      reactionStore,
+				},
+				store:    reactionStore,
+				answerer: answerer,
+				drafts: map[string]*replyDraft{
+					"first": {reaction: reaction, text: "Спасибо!", chatId: "10", previewId: 1, createdAt: time.Now()},
+					"other": {reaction: reaction, text: "Благодарим!", chatId: "20", previewId: 2, createdAt: time.Now()},
+				},
+				posted: make(map[string]time.Time),
+			}
+
+			post := func(draftId string) {
+				bot.handleReplyCallback(TelegramCallbackQuery{
+					Id:      "query",
+					From:    TelegramUser{Id: 1},
+					Message: &TelegramIncomingMessage{},
+					Data:    callbackData(replyAction, postDecision, draftId),
+				})
+			}
+
+			// The other admin publishes right after, from another chat.
+			post("first")
+			post("other")
+
+			if answerer.posts != test.wantPosts {
+				t.Errorf("answer posted %d times, want %d", answerer.posts, test.wantPosts)
+			}
+			if drafts := slices.Sorted(maps.Keys(bot.drafts)); !slices.Equal(drafts, test.wantDrafts) {
+				t.Errorf("drafts left %v, want %v", drafts, test.wantDrafts)
+			}
+			if answered := bot.isAnswered(reaction.Id); answered != test.wantAnswered {
+				t.Errorf("answered is %v, want %v", answered, test.wantAnswered)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/report"
+	"marketplace-notifications/internal/utils/format"
+	"math"
+	"sort"
+	"strings"
+)
+
+const reportDateLayout = "02.01.2006"
+
+// SendRatingReport sends the report to a single chat, or a thread of it.
+func (notifier *TelegramNotifier) SendRatingReport(ctx context.Context, chatId string, threadId int, ratingReport report.Report) error {
+	message := TelegramMessage{
+		ChatId:          chatId,
+		MessageThreadId: threadId,
+		Text:            formatRatingReport(ratingReport),
+		ParseMode:       "MarkdownV2",
+	}
+
+	if _, err := notifier.sendMessage(message); err != nil {
+		return fmt.Errorf("failed to send %s report to %s: %w", ratingReport.Kind, chatId, err)
+	}
+
+	return nil
+}
+
+func formatRatingReport(ratingReport report.Report) string {
+	var message strings.Builder
+
+	current, previous := ratingReport.Current, ratingReport.Previous
+	lastDay := ratingReport.To.AddDate(0, 0, -1)
+
+	if ratingReport.Kind == report.Weekly {
+		message.WriteString("📊 *Еженедельный отчёт по отзывам* 📊\n\n")
+		message.WriteString(fmt.Sprintf("🗓️  *Период:* %s – %s\n\n", format.EscapeMarkdown(ratingReport.From.Format(reportDateLayout)), format.EscapeMarkdown(lastDay.Format(reportDateLayout))))
+	} else {
+		message.WriteString("📊 *Ежедневный отчёт по отзывам* 📊\n\n")
+		message.WriteString(fmt.Sprintf("🗓️  *День:* %s\n\n", format.EscapeMarkdown(lastDay.Format(reportDateLayout))))
+	}
+
+	message.WriteString(fmt.Sprintf("💬  *Новых отзывов:* %d%s\n", current.TotalFeedbacks(), formatCountChange(current.TotalFeedbacks(), previous.TotalFeedbacks())))
+	for _, marketplace := range reportMarketplaces(ratingReport) {
+		message.WriteString(fmt.Sprintf("      • %s: %d%s\n", format.EscapeMarkdown(marketplace), current.Feedbacks[marketplace], formatCountChange(current.Feedbacks[marketplace], previous.Feedbacks[marketplace])))
+	}
+
+	if current.Rated == 0 {
+		message.WriteString("\n⭐  *Средняя оценка:* нет оценок\n")
+	} else {
+		message.WriteString(fmt.Sprintf("\n⭐  *Средняя оценка:* %s%s\n", format.EscapeMarkdown(fmt.Sprintf("%.2f", current.Average())), formatAverageChange(current.Average(), previous.Average(), previous.Rated > 0)))
+		message.WriteString(fmt.Sprintf("👎  *Доля оценок 1–2:* %s%s\n", format.EscapeMarkdown(fmt.Sprintf("%.1f%%", current.NegativeShare())), formatShareChange(current.NegativeShare(), previous.NegativeShare(), previous.Rated > 0)))
+	}
+
+	if len(ratingReport.Articles) > 0 {
+		message.WriteString("\n📦  *Оценки по артикулам:*\n")
+		for _, article := range ratingReport.Articles {
+			message.WriteString(fmt.Sprintf("      • %s — %s, отзывов: %d%s\n",
+				formatReportArticle(article.Article, article.ProductName),
+				format.EscapeMarkdown(fmt.Sprintf("%.2f", article.Rating.Average())),
+				article.Rating.Count,
+				formatAverageChange(article.Rating.Average(), article.PreviousRating.Average(), article.PreviousRating.Count > 0)))
+		}
+		if ratingReport.MoreArticles > 0 {
+			message.WriteString(fmt.Sprintf("      _и ещё артикулов: %d_\n", ratingReport.MoreArticles))
+		}
+	}
+
+	if len(ratingReport.QuestionedItems) > 0 {
+		message.WriteString("\n❔  *Больше всего вопросов:*\n")
+		for _, item := range ratingReport.QuestionedItems {
+			message.WriteString(fmt.Sprintf("      • %s — %d\n", formatReportArticle(item.Article, item.ProductName), item.Questions))
+		}
+	}
+
+	// The history only holds items that were found unanswered.
+	message.WriteString("\n_" + format.EscapeMarkdown("Учтены только отзывы и вопросы, найденные без ответа: отвеченные до проверки в отчёт не попадают.") + "_\n")
+
+	return message.String()
+}
+
+// reportMarketplaces lists the marketplaces with feedbacks in either period.
+func reportMarketplaces(ratingReport report.Report) []string {
+	seen := make(map[string]bool)
+	for _, stats := range []report.Stats{ratingReport.Current, ratingReport.Previous} {
+		for marketplace := range stats.Feedbacks {
+			seen[marketplace] = true
+		}
+	}
+
+	marketplaces := make([]string, 0, len(seen))
+	for marketplace := range seen {
+		marketplaces = append(marketplaces, marketplace)
+	}
+	sort.Strings(marketplaces)
+
+	return marketplaces
+}
+
+func formatReportArticle(article, productName string) string {
+	if productName == "" {
+		return fmt.Sprintf("`%s`", format.EscapeMarkdown(article))
+	}
+
+	return fmt.Sprintf("`%s` %s", format.EscapeMarkdown(article), format.EscapeMarkdown(productName))
+}
+
+func formatCountChange(current, previous int) string {
+	return formatChange(float64(current-previous), 0, "")
+}
+
+func formatAverageChange(current, previous float64, comparable bool) string {
+	if !comparable {
+		return ""
+	}
+
+	return formatChange(current-previous, 2, "")
+}
+
+func formatShareChange(current, previous float64, comparable bool) string {
+	if !comparable {
+		return ""
+	}
+
+	return formatChange(current-previous, 1, " п.п.")
+}
+
+// formatChange shows the change against the previous period, rounded to the
+// precision the value itself is shown with.
+func formatChange(delta float64, precision int, unit string) string {
+	scale := math.Pow(10, float64(precision))
+	delta = math.Round(delta*scale) / scale
+
+	switch {
+	case delta > 0:
+		return " " + format.EscapeMarkdown(fmt.Sprintf("(▲ +%.*f%s)", precision, delta, unit))
+	case delta < 0:
+		return " " + format.EscapeMarkdown(fmt.Sprintf("(▼ %.*f%s)", precision, delta, unit))
+	default:
+		return " " + format.EscapeMarkdown("(=)")
+	}
+}
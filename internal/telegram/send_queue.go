@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// sendQueue hands out the Telegram rate limit one call at a time, urgent
+// calls ahead of the others and each group in arrival order.
+type sendQueue struct {
+	limiter *rate.Limiter
+	mutex   sync.Mutex
+	busy    bool
+	urgent  []chan struct{}
+	normal  []chan struct{}
+}
+
+func newSendQueue(rps int) *sendQueue {
+	return &sendQueue{limiter: rate.NewLimiter(rate.Limit(rps), rps)}
+}
+
+func (queue *sendQueue) wait(ctx context.Context, urgent bool) error {
+	queue.mutex.Lock()
+	if !queue.busy {
+		queue.busy = true
+		queue.mutex.Unlock()
+	} else {
+		turn := make(chan struct{})
+		if urgent {
+			queue.urgent = append(queue.urgent, turn)
+		} else {
+			queue.normal = append(queue.normal, turn)
+		}
+		queue.mutex.Unlock()
+
+		<-turn
+	}
+
+	defer queue.next()
+
+	return queue.limiter.Wait(ctx)
+}
+
+// next passes the turn to the first waiting call.
+func (queue *sendQueue) next() {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	switch {
+	case len(queue.urgent) > 0:
+		close(queue.urgent[0])
+		queue.urgent = queue.urgent[1:]
+	case len(queue.normal) > 0:
+		close(queue.normal[0])
+		queue.normal = queue.normal[1:]
+	default:
+		queue.busy = false
+	}
+}
@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSendQueueOrder(t *testing.T) {
+	queue := newSendQueue(1000)
+	queue.busy = true
+
+	turns := make(map[string]chan struct{})
+	for _, waiter := range []struct {
+		name   string
+		urgent bool
+	}{
+		{"normal 1", false},
+		{"urgent 1", true},
+		{"normal 2", false},
+		{"urgent 2", true},
+	} {
+		turn := make(chan struct{})
+		turns[waiter.name] = turn
+		if waiter.urgent {
+			queue.urgent = append(queue.urgent, turn)
+		} else {
+			queue.normal = append(queue.normal, turn)
+		}
+	}
+
+	for _, want := range []string{"urgent 1", "urgent 2", "normal 1", "normal 2"} {
+		queue.next()
+
+		for name, turn := range turns {
+			select {
+			case <-turn:
+				if name != want {
+					t.Fatalf("%s got the turn, want %s", name, want)
+				}
+				delete(turns, name)
+			default:
+			}
+		}
+	}
+
+	queue.next()
+	if queue.busy {
+		t.Fatal("queue is still busy with nobody waiting")
+	}
+}
+
+func TestSendQueueWait(t *testing.T) {
+	queue := newSendQueue(1000)
+
+	if err := queue.wait(context.Background(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queue.busy {
+		t.Fatal("queue is still busy after the call")
+	}
+
+	// A waiting call goes once the running one is done.
+	queue.busy = true
+	done := make(chan error)
+	go func() { done <- queue.wait(context.Background(), true) }()
+
+	waitForWaiters(t, queue, 1)
+	queue.next()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting call never got its turn")
+	}
+}
+
+func waitForWaiters(t *testing.T, queue *sendQueue, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		queue.mutex.Lock()
+		waiting := len(queue.urgent) + len(queue.normal)
+		queue.mutex.Unlock()
+
+		if waiting == count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("expected %d waiting calls", count)
+}
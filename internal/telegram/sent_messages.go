@@ -0,0 +1,25 @@
+package telegram
+
+import (
+	"marketplace-notifications/internal/marketplaces"
+	"marketplace-notifications/internal/metrics"
+	"marketplace-notifications/internal/store"
+)
+
+type sentMessageKey struct {
+	reactionType marketplaces.UserReactionType
+	serviceName  string
+	account      string
+	id           string
+}
+
+// updateUnansweredGauge counts the WB items still unanswered in the reaction
+// history, which the answered checks go through.
+func (notifier *TelegramNotifier) updateUnansweredGauge() {
+	unanswered := false
+
+	for _, reactionType := range []marketplaces.UserReactionType{marketplaces.Question, marketplaces.Feedback} {
+		reactions := notifier.store.AllReactions(store.ReactionFilter{Marketplace: "WB", Type: reactionType.String(), Answered: &unanswered})
+		metrics.UnansweredItems.Set(float64(len(reactions)), "WB", reactionType.String())
+	}
+}
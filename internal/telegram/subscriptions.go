@@ -0,0 +1,220 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"marketplace-notifications/internal/store"
+	"marketplace-notifications/internal/utils/format"
+	"strings"
+)
+
+const (
+	subscribeAction   = "subscribe"
+	unsubscribeAction = "unsubscribe"
+	approveDecision   = "approve"
+	rejectDecision    = "reject"
+)
+
+func (bot *TelegramBot) requestSubscription(chat TelegramChat, user TelegramUser, threadId int) {
+	chatId := chat.ChatId()
+
+	if subscription, ok := bot.store.Subscription(chatId); ok && subscription.Status == store.SubscriptionActive {
+		bot.reply(chatId, threadId, "ℹ️ Этот чат уже подписан на уведомления\\.")
+		return
+	}
+
+	if bot.isAdmin(user) {
+		bot.setSubscriptionStatus(chat, user, store.SubscriptionActive)
+		bot.reply(chatId, threadId, "✅ Чат подписан на уведомления\\.")
+		return
+	}
+
+	bot.setSubscriptionStatus(chat, user, store.SubscriptionPending)
+	bot.requestApproval(subscribeAction, chat, user)
+	bot.reply(chatId, threadId, "⏳ Запрос на подписку отправлен администраторам\\.")
+}
+
+func (bot *TelegramBot) requestUnsubscription(chat TelegramChat, user TelegramUser, threadId int) {
+	chatId := chat.ChatId()
+
+	if subscription, ok := bot.store.Subscription(chatId); !ok || subscription.Status != store.SubscriptionActive {
+		bot.reply(chatId, threadId, "ℹ️ Этот чат не подписан на уведомления\\.")
+		return
+	}
+
+	if bot.isAdmin(user) {
+		bot.setSubscriptionStatus(chat, user, store.SubscriptionRemoved)
+		bot.reply(chatId, threadId, "🔕 Чат отписан от уведомлений\\.")
+		return
+	}
+
+	bot.requestApproval(unsubscribeAction, chat, user)
+	bot.reply(chatId, threadId, "⏳ Запрос на отписку отправлен администраторам\\.")
+}
+
+func (bot *TelegramBot) handleMyChatMember(update TelegramChatMemberUpdated) {
+	chatId := update.Chat.ChatId()
+
+	switch {
+	case update.NewChatMember.IsPresent() && !update.OldChatMember.IsPresent():
+		slog.Info("Bot was added to chat", "chat", chatId, "userId", update.From.Id)
+		bot.requestSubscription(update.Chat, update.From, 0)
+	case !update.NewChatMember.IsPresent() && update.OldChatMember.IsPresent():
+		slog.Info("Bot was removed from chat", "chat", chatId)
+		bot.setSubscriptionStatus(update.Chat, update.From, store.SubscriptionRemoved)
+	}
+}
+
+func (bot *TelegramBot) migrateChat(oldChatId, newChatId string) {
+	if err := bot.store.MigrateSubscription(oldChatId, newChatId); err != nil {
+		slog.Error("Failed to migrate chat subscription", "chat", oldChatId, "newChat", newChatId, "error", err)
+		return
+	}
+
+	slog.Info("Chat was migrated", "chat", oldChatId, "newChat", newChatId)
+}
+
+func (bot *TelegramBot) requestApproval(action string, chat TelegramChat, user TelegramUser) {
+	adminIds := bot.notifier.currentConfig().AdminIds
+	if len(adminIds) == 0 {
+		slog.Warn("No TELEGRAM_ADMIN_IDS configured to approve request", "action", action, "chat", chat.ChatId())
+		return
+	}
+
+	var text strings.Builder
+	if action == subscribeAction {
+		text.WriteString("🔔 *Запрос на подписку*\n\n")
+	} else {
+		text.WriteString("🔕 *Запрос на отписку*\n\n")
+	}
+	text.WriteString(fmt.Sprintf("💬  *Чат:* %s \\(%s\\)\n", format.EscapeMarkdown(chat.DisplayName()), format.EscapeMarkdown(chat.ChatId())))
+	text.WriteString(fmt.Sprintf("👤  *Запросил:* %s\n", format.EscapeMarkdown(user.DisplayName())))
+
+	markup := &InlineKeyboardMarkup{
+		InlineKeyboard: [][]InlineKeyboardButton{{
+			{Text: "✅ Одобрить", CallbackData: callbackData(action, approveDecision, chat.ChatId())},
+			{Text: "❌ Отклонить", CallbackData: callbackData(action, rejectDecision, chat.ChatId())},
+		}},
+	}
+
+	for _, adminId := range adminIds {
+		message := TelegramMessage{
+			ChatId:      adminId,
+			Text:        text.String(),
+			ParseMode:   "MarkdownV2",
+			ReplyMarkup: markup,
+		}
+
+		if _, err := bot.notifier.sendMessage(message); err != nil {
+			slog.Error("Failed to send request to admin", "action", action, "chat", adminId, "error", err)
+		}
+	}
+}
+
+func (bot *TelegramBot) handleCallbackQuery(query TelegramCallbackQuery) {
+	if isReplyCallback(query.Data) {
+		bot.handleReplyCallback(query)
+		return
+	}
+
+	action, decision, chatId, ok := parseCallbackData(query.Data)
+	if !ok {
+		return
+	}
+
+	if !bot.isAdmin(query.From) {
+		bot.answerCallbackQuery(query.Id, "Только администраторы могут принимать решения")
+		return
+	}
+
+	subscription, ok := bot.store.Subscription(chatId)
+	if !ok {
+		bot.answerCallbackQuery(query.Id, "Чат не найден")
+		return
+	}
+
+	var result string
+
+	switch {
+	case action == subscribeAction && decision == approveDecision:
+		bot.updateSubscriptionStatus(chatId, store.SubscriptionActive)
+		bot.reply(chatId, 0, "✅ Подписка на уведомления одобрена\\.")
+		result = "✅ Подписка одобрена"
+	case action == subscribeAction:
+		bot.updateSubscriptionStatus(chatId, store.SubscriptionRemoved)
+		bot.reply(chatId, 0, "❌ Запрос на подписку отклонён\\.")
+		result = "❌ Подписка отклонена"
+	case decision == approveDecision:
+		bot.updateSubscriptionStatus(chatId, store.SubscriptionRemoved)
+		bot.reply(chatId, 0, "🔕 Чат отписан от уведомлений\\.")
+		result = "✅ Отписка одобрена"
+	default:
+		bot.reply(chatId, 0, "❌ Запрос на отписку отклонён\\.")
+		result = "❌ Отписка отклонена"
+	}
+
+	bot.answerCallbackQuery(query.Id, result)
+
+	if query.Message != nil {
+		message := TelegramEditMessage{
+			ChatId:    query.Message.Chat.ChatId(),
+			MessageId: query.Message.MessageId,
+			Text:      fmt.Sprintf("%s: %s \\(%s\\), решение принял %s", result, format.EscapeMarkdown(subscription.Title), format.EscapeMarkdown(chatId), format.EscapeMarkdown(query.From.DisplayName())),
+			ParseMode: "MarkdownV2",
+		}
+
+		if err := bot.notifier.editMessage(message); err != nil {
+			slog.Error("Failed to update approval request message", "error", err)
+		}
+	}
+}
+
+func (bot *TelegramBot) setSubscriptionStatus(chat TelegramChat, user TelegramUser, status store.SubscriptionStatus) {
+	if err := bot.store.SetSubscriptionStatus(chat.ChatId(), chat.DisplayName(), status, user.DisplayName()); err != nil {
+		slog.Error("Failed to save chat subscription", "chat", chat.ChatId(), "error", err)
+		return
+	}
+
+	slog.Info("Chat subscription changed", "chat", chat.ChatId(), "status", status)
+}
+
+func (bot *TelegramBot) updateSubscriptionStatus(chatId string, status store.SubscriptionStatus) {
+	if err := bot.store.SetSubscriptionStatus(chatId, "", status, ""); err != nil {
+		slog.Error("Failed to save chat subscription", "chat", chatId, "error", err)
+		return
+	}
+
+	slog.Info("Chat subscription changed", "chat", chatId, "status", status)
+}
+
+func (bot *TelegramBot) answerCallbackQuery(queryId, text string) {
+	answer := TelegramCallbackAnswer{
+		CallbackQueryId: queryId,
+		Text:            text,
+	}
+
+	if err := bot.notifier.callMethod("answerCallbackQuery", answer, nil); err != nil {
+		slog.Error("Failed to answer callback query", "error", err)
+	}
+}
+
+func callbackData(action, decision, chatId string) string {
+	return strings.Join([]string{action, decision, chatId}, ":")
+}
+
+func parseCallbackData(data string) (action, decision, chatId string, ok bool) {
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+
+	action, decision, chatId = parts[0], parts[1], parts[2]
+	if action != subscribeAction && action != unsubscribeAction {
+		return "", "", "", false
+	}
+	if decision != approveDecision && decision != rejectDecision {
+		return "", "", "", false
+	}
+
+	return action, decision, chatId, true
+}
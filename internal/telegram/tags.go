@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"marketplace-notifications/internal/logging"
+	"marketplace-notifications/internal/store"
+	"marketplace-notifications/internal/utils/format"
+	"strings"
+)
+
+// sendToTagRoutes sends the notification to the chats routed by its tags,
+// unless a chat already got it.
+func (notifier *TelegramNotifier) sendToTagRoutes(ctx context.Context, text string, reaction store.Reaction, sent []store.Delivery, markup *InlineKeyboardMarkup) []store.Delivery {
+	if len(reaction.Tags) == 0 {
+		return nil
+	}
+
+	delivered := make(map[string]bool)
+	for _, delivery := range sent {
+		delivered[delivery.ChatId] = true
+	}
+
+	var deliveries []store.Delivery
+
+	for _, route := range notifier.currentConfig().Tagging.Routes {
+		if delivered[route.ChatId] || !route.Matches(reaction.Tags) {
+			continue
+		}
+		delivered[route.ChatId] = true
+
+		message := TelegramMessage{
+			ChatId:          route.ChatId,
+			MessageThreadId: route.ThreadId,
+			Text:            text,
+			ParseMode:       "MarkdownV2",
+			ReplyMarkup:     markup,
+		}
+
+		messageId, err := notifier.send(message, reaction.Priority)
+		deliveries = append(deliveries, newDelivery(route.ChatId, store.DeliverySend, messageId, err))
+
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to send notification to tag route", "chat", route.ChatId, "error", err)
+		}
+	}
+
+	return deliveries
+}
+
+// formatTags shows the tags as hashtags, so that chats can be searched by
+// them.
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	hashtags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		hashtags = append(hashtags, format.EscapeMarkdown("#"+tag))
+	}
+
+	return fmt.Sprintf("\n🏷️  %s\n", strings.Join(hashtags, " "))
+}
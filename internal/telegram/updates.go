@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+)
+
+type TelegramUpdate struct {
+	UpdateId      int                        `json:"update_id"`
+	Message       *TelegramIncomingMessage   `json:"message"`
+	MyChatMember  *TelegramChatMemberUpdated `json:"my_chat_member"`
+	CallbackQuery *TelegramCallbackQuery     `json:"callback_query"`
+}
+
+type TelegramChat struct {
+	Id        int64  `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	IsForum   bool   `json:"is_forum"`
+}
+
+type TelegramUser struct {
+	Id        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
+type TelegramIncomingMessage struct {
+	MessageId       int                      `json:"message_id"`
+	MessageThreadId int                      `json:"message_thread_id"`
+	From            *TelegramUser            `json:"from"`
+	Chat            TelegramChat             `json:"chat"`
+	Text            string                   `json:"text"`
+	MigrateToChatId int64                    `json:"migrate_to_chat_id"`
+	ReplyToMessage  *TelegramIncomingMessage `json:"reply_to_message"`
+}
+
+type TelegramChatMemberUpdated struct {
+	Chat          TelegramChat       `json:"chat"`
+	From          TelegramUser       `json:"from"`
+	OldChatMember TelegramChatMember `json:"old_chat_member"`
+	NewChatMember TelegramChatMember `json:"new_chat_member"`
+}
+
+type TelegramChatMember struct {
+	Status string `json:"status"`
+}
+
+type TelegramCallbackQuery struct {
+	Id      string                   `json:"id"`
+	From    TelegramUser             `json:"from"`
+	Message *TelegramIncomingMessage `json:"message"`
+	Data    string                   `json:"data"`
+}
+
+type TelegramGetUpdates struct {
+	Offset         int      `json:"offset"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+type TelegramCallbackAnswer struct {
+	CallbackQueryId string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+func (chat TelegramChat) ChatId() string {
+	return strconv.FormatInt(chat.Id, 10)
+}
+
+func (chat TelegramChat) DisplayName() string {
+	switch {
+	case chat.Title != "":
+		return chat.Title
+	case chat.Username != "":
+		return "@" + chat.Username
+	default:
+		return chat.FirstName
+	}
+}
+
+func (user TelegramUser) DisplayName() string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+
+	return user.FirstName
+}
+
+func (member TelegramChatMember) IsPresent() bool {
+	switch member.Status {
+	case "creator", "administrator", "member", "restricted":
+		return true
+	default:
+		return false
+	}
+}
+
+func (bot *TelegramBot) getUpdates(ctx context.Context) ([]TelegramUpdate, error) {
+	request := TelegramGetUpdates{
+		Offset:         bot.offset,
+		Timeout:        int(bot.notifier.currentConfig().UpdatesTimeout.Seconds()),
+		AllowedUpdates: []string{"message", "my_chat_member", "callback_query"},
+	}
+
+	var updates []TelegramUpdate
+	if err := bot.notifier.doRequest(ctx, bot.httpClient, "getUpdates", request, &updates); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
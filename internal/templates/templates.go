@@ -0,0 +1,139 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	SourceConfig = "config"
+	SourceAPI    = "api"
+)
+
+// maxNameLength keeps the name readable on a Telegram button.
+const maxNameLength = 40
+
+var (
+	idPattern          = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+	placeholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+var placeholders = []string{"product", "article", "customer", "stars"}
+
+// Template is a canned answer. It is offered for reactions of Type with a
+// rating from MinRating to MaxRating and any of Tags; empty conditions match
+// everything.
+type Template struct {
+	Id        string   `yaml:"id" json:"id"`
+	Name      string   `yaml:"name" json:"name"`
+	Text      string   `yaml:"text" json:"text"`
+	Type      string   `yaml:"type" json:"type,omitempty"`
+	MinRating int      `yaml:"minRating" json:"minRating,omitempty"`
+	MaxRating int      `yaml:"maxRating" json:"maxRating,omitempty"`
+	Tags      []string `yaml:"tags" json:"tags,omitempty"`
+	Source    string   `yaml:"-" json:"source,omitempty"`
+}
+
+// Values fill in the placeholders of a template.
+type Values struct {
+	Product  string
+	Article  string
+	Customer string
+	Stars    int
+}
+
+func (template Template) Validate() error {
+	if !idPattern.MatchString(template.Id) {
+		return fmt.Errorf("template id %q must be 1 to 32 lowercase letters, digits, - or _", template.Id)
+	}
+	if template.Name == "" || utf8.RuneCountInString(template.Name) > maxNameLength {
+		return fmt.Errorf("template %s needs a name of at most %d characters", template.Id, maxNameLength)
+	}
+	if strings.TrimSpace(template.Text) == "" {
+		return fmt.Errorf("template %s has no text", template.Id)
+	}
+	if template.Type != "" && template.Type != "questions" && template.Type != "feedbacks" {
+		return fmt.Errorf("template %s has unknown type %q, expected questions or feedbacks", template.Id, template.Type)
+	}
+	if template.MinRating < 0 || template.MinRating > 5 || template.MaxRating < 0 || template.MaxRating > 5 {
+		return fmt.Errorf("template %s ratings must be between 1 and 5", template.Id)
+	}
+	if template.MinRating > 0 && template.MaxRating > 0 && template.MinRating > template.MaxRating {
+		return fmt.Errorf("template %s min rating is above its max rating", template.Id)
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template.Text, -1) {
+		if !slices.Contains(placeholders, match[1]) {
+			return fmt.Errorf("template %s has unknown placeholder %s, expected one of {%s}", template.Id, match[0], strings.Join(placeholders, "}, {"))
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the template is offered for a reaction. A rating
+// range never matches questions, which have no rating.
+func (template Template) Matches(reactionType string, rating int, tags []string) bool {
+	if template.Type != "" && template.Type != reactionType {
+		return false
+	}
+
+	if template.MinRating > 0 && rating < template.MinRating {
+		return false
+	}
+	if template.MaxRating > 0 && (rating == 0 || rating > template.MaxRating) {
+		return false
+	}
+
+	if len(template.Tags) > 0 && !slices.ContainsFunc(template.Tags, func(tag string) bool {
+		return slices.Contains(tags, strings.ToLower(tag))
+	}) {
+		return false
+	}
+
+	return true
+}
+
+func (template Template) Fill(values Values) string {
+	stars := ""
+	if values.Stars > 0 {
+		stars = strconv.Itoa(values.Stars)
+	}
+
+	replacer := strings.NewReplacer(
+		"{product}", values.Product,
+		"{article}", values.Article,
+		"{customer}", values.Customer,
+		"{stars}", stars,
+	)
+
+	return strings.TrimSpace(replacer.Replace(template.Text))
+}
+
+// Merge lists the configured templates followed by the ones added through the
+// API, which can't replace configured ones.
+func Merge(configured, added []Template) []Template {
+	merged := make([]Template, 0, len(configured)+len(added))
+	ids := make(map[string]bool)
+
+	for _, template := range configured {
+		template.Source = SourceConfig
+		merged = append(merged, template)
+		ids[template.Id] = true
+	}
+
+	for _, template := range added {
+		if ids[template.Id] {
+			continue
+		}
+
+		template.Source = SourceAPI
+		merged = append(merged, template)
+	}
+
+	return merged
+}
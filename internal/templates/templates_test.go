@@ -0,0 +1,196 @@
+package templates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateValidate(t *testing.T) {
+	valid := Template{Id: "thanks", Name: "Спасибо", Text: "Спасибо за отзыв о {product}!"}
+
+	tests := []struct {
+		name    string
+		change  func(template *Template)
+		wantErr string
+	}{
+		{name: "valid", change: func(template *Template) {}},
+		{name: "all placeholders", change: func(template *Template) {
+			template.Text = "{customer}, спасибо за {stars} звёзд для {product} ({article})"
+		}},
+		{name: "rating range", change: func(template *Template) {
+			template.Type, template.MinRating, template.MaxRating = "feedbacks", 4, 5
+		}},
+		{
+			name:    "uppercase id",
+			change:  func(template *Template) { template.Id = "Thanks" },
+			wantErr: `template id "Thanks" must be 1 to 32 lowercase letters, digits, - or _`,
+		},
+		{
+			name:    "empty id",
+			change:  func(template *Template) { template.Id = "" },
+			wantErr: `template id "" must be 1 to 32 lowercase letters, digits, - or _`,
+		},
+		{
+			name:    "no name",
+			change:  func(template *Template) { template.Name = "" },
+			wantErr: "template thanks needs a name of at most 40 characters",
+		},
+		{
+			name:    "long name",
+			change:  func(template *Template) { template.Name = strings.Repeat("я", 41) },
+			wantErr: "template thanks needs a name of at most 40 characters",
+		},
+		{
+			name:    "blank text",
+			change:  func(template *Template) { template.Text = " \n" },
+			wantErr: "template thanks has no text",
+		},
+		{
+			name:    "unknown type",
+			change:  func(template *Template) { template.Type = "reviews" },
+			wantErr: `template thanks has unknown type "reviews", expected questions or feedbacks`,
+		},
+		{
+			name:    "rating out of range",
+			change:  func(template *Template) { template.MaxRating = 6 },
+			wantErr: "template thanks ratings must be between 1 and 5",
+		},
+		{
+			name:    "min above max",
+			change:  func(template *Template) { template.MinRating, template.MaxRating = 4, 2 },
+			wantErr: "template thanks min rating is above its max rating",
+		},
+		{
+			name:    "unknown placeholder",
+			change:  func(template *Template) { template.Text = "Здравствуйте, {name}" },
+			wantErr: "template thanks has unknown placeholder {name}, expected one of {product}, {article}, {customer}, {stars}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template := valid
+			test.change(&template)
+
+			err := template.Validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.wantErr {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplateMatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     Template
+		reactionType string
+		rating       int
+		tags         []string
+		want         bool
+	}{
+		{name: "no conditions", template: Template{}, reactionType: "questions", want: true},
+		{name: "type matches", template: Template{Type: "feedbacks"}, reactionType: "feedbacks", rating: 3, want: true},
+		{name: "other type", template: Template{Type: "feedbacks"}, reactionType: "questions", want: false},
+		{name: "within range", template: Template{MinRating: 4, MaxRating: 5}, reactionType: "feedbacks", rating: 4, want: true},
+		{name: "below min", template: Template{MinRating: 4}, reactionType: "feedbacks", rating: 3, want: false},
+		{name: "above max", template: Template{MaxRating: 2}, reactionType: "feedbacks", rating: 3, want: false},
+		{name: "max without rating", template: Template{MaxRating: 2}, reactionType: "questions", want: false},
+		{name: "min without rating", template: Template{MinRating: 1}, reactionType: "questions", want: false},
+		{name: "any tag", template: Template{Tags: []string{"delivery", "size"}}, reactionType: "feedbacks", tags: []string{"size"}, want: true},
+		{name: "tag case", template: Template{Tags: []string{"Delivery"}}, reactionType: "feedbacks", tags: []string{"delivery"}, want: true},
+		{name: "no tag", template: Template{Tags: []string{"delivery"}}, reactionType: "feedbacks", tags: []string{"size"}, want: false},
+		{name: "tags but untagged", template: Template{Tags: []string{"delivery"}}, reactionType: "feedbacks", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.template.Matches(test.reactionType, test.rating, test.tags); got != test.want {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFill(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		values Values
+		want   string
+	}{
+		{
+			name:   "all values",
+			text:   "{customer}, спасибо за {stars}★ для {product} ({article})",
+			values: Values{Product: "Кружка", Article: "123", Customer: "Анна", Stars: 5},
+			want:   "Анна, спасибо за 5★ для Кружка (123)",
+		},
+		{
+			name:   "missing values",
+			text:   "{customer} спасибо за {stars}",
+			values: Values{},
+			want:   "спасибо за",
+		},
+		{
+			name:   "repeated placeholder",
+			text:   "{product} и снова {product}",
+			values: Values{Product: "Кружка"},
+			want:   "Кружка и снова Кружка",
+		},
+		{
+			name:   "values are not filled again",
+			text:   "{customer}",
+			values: Values{Customer: "{product}", Product: "Кружка"},
+			want:   "{product}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := (Template{Text: test.text}).Fill(test.values); got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured []Template
+		added      []Template
+		want       []Template
+	}{
+		{
+			name: "nothing",
+			want: []Template{},
+		},
+		{
+			name:       "configured first",
+			configured: []Template{{Id: "b"}},
+			added:      []Template{{Id: "a"}},
+			want:       []Template{{Id: "b", Source: SourceConfig}, {Id: "a", Source: SourceAPI}},
+		},
+		{
+			name:       "configured win",
+			configured: []Template{{Id: "thanks", Text: "config"}},
+			added:      []Template{{Id: "thanks", Text: "api"}, {Id: "sorry"}},
+			want:       []Template{{Id: "thanks", Text: "config", Source: SourceConfig}, {Id: "sorry", Source: SourceAPI}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Merge(test.configured, test.added); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
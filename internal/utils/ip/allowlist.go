@@ -0,0 +1,50 @@
+package ip
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Allowlist is a parsed list of IPv4 and IPv6 networks.
+type Allowlist []netip.Prefix
+
+// ParseAllowlist accepts CIDRs as well as single addresses.
+func ParseAllowlist(entries []string) (Allowlist, error) {
+	allowlist := make(Allowlist, 0, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP address or CIDR %q", entry)
+			}
+
+			allowlist = append(allowlist, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR %q", entry)
+		}
+
+		allowlist = append(allowlist, prefix.Masked())
+	}
+
+	return allowlist, nil
+}
+
+func (allowlist Allowlist) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range allowlist {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+package ip
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseAllowlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "IPv4 CIDR", entries: []string{"5.45.207.0/25"}},
+		{name: "IPv6 CIDR", entries: []string{"2a02:6b8::/32"}},
+		{name: "single addresses", entries: []string{" 10.0.0.1 ", "::1"}},
+		{name: "not an address", entries: []string{"example.com"}, wantErr: `invalid IP address or CIDR "example.com"`},
+		{name: "prefix too long", entries: []string{"10.0.0.0/33"}, wantErr: `invalid IP address or CIDR "10.0.0.0/33"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allowlist, err := ParseAllowlist(test.entries)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(allowlist) != len(test.entries) {
+				t.Fatalf("got %d networks, want %d", len(allowlist), len(test.entries))
+			}
+		})
+	}
+}
+
+func TestAllowlistContains(t *testing.T) {
+	allowlist, err := ParseAllowlist([]string{"5.45.207.0/25", "10.0.0.1", "2a02:6b8::/32"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"5.45.207.0", true},
+		{"5.45.207.127", true},
+		{"5.45.207.128", false},
+		{"10.0.0.1", true},
+		{"10.0.0.2", false},
+		{"::ffff:5.45.207.1", true},
+		{"2a02:6b8:c00::1", true},
+		{"2a02:6b9::1", false},
+	}
+
+	for _, test := range tests {
+		if got := allowlist.Contains(netip.MustParseAddr(test.addr)); got != test.want {
+			t.Errorf("Contains(%s) = %v, want %v", test.addr, got, test.want)
+		}
+	}
+}
+
+func TestEmptyAllowlistContainsNothing(t *testing.T) {
+	var allowlist Allowlist
+
+	if allowlist.Contains(netip.MustParseAddr("127.0.0.1")) {
+		t.Fatal("an empty allowlist must not allow any address")
+	}
+}
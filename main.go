@@ -1,11 +1,28 @@
 package main
 
 import (
+	"flag"
+	"log/slog"
 	"marketplace-notifications/internal/app"
+	"os"
 )
 
 func main() {
-	app := app.NewApp()
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := app.RunExport(os.Args[2:]); err != nil {
+			slog.Error("Export failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	app.Run()
+	configPath := flag.String("config", "", "path to an optional YAML config file")
+	flag.Parse()
+
+	app := app.NewApp(*configPath)
+
+	if err := app.Run(); err != nil {
+		slog.Error("Application stopped with an error", "error", err)
+		os.Exit(1)
+	}
 }